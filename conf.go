@@ -2,19 +2,61 @@
 // Configuration file format:
 // 	#comment
 // 	;comment
+// 	global=key before the first section, read with section ""
 // 	[section]
 // 	value=key
+// 	empty=
+// 	spaced = whitespace around keys and values is trimmed
+// 	list[]=first element
+// 	list[]=second element
+// 	quoted="  value with spaces and \"escapes\"\n"
+// 	continued=first line \
+// 	second line
+// 	commented=value ;comment
+// 	multiline="""
+// 	verbatim text
+// 	spanning lines"""
 package conf
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
 	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
 )
 
 type Conf struct {
 	filename string
 	data     map[string]map[string]string
+	lists    map[string]map[string][]string
+	parents  map[string]string
+	layout   []item
+	included *Conf
+	opts     options
+}
+
+const (
+	itemText = iota
+	itemSection
+	itemKey
+)
+
+// item is a piece of the parsed file used to reproduce its layout when writing.
+type item struct {
+	kind     int
+	section  string
+	key      string
+	list     bool
+	shadowed bool
+	value    string
+	text     string
+	line     int
 }
 
 const (
@@ -24,43 +66,305 @@ const (
 	stateSection
 	stateKey
 	stateValue
+	stateQuoted
+	stateQuotedEnd
+	stateMultiline
 	stateError
 	stateEOF
 )
 
+// escapes maps the characters following a backslash in quoted values to their meaning.
+var escapes = map[string]string{"n": "\n", "t": "\t", "\\": "\\", "\"": "\""}
+
 type lexer struct {
-	file *os.File
+	reader *bufio.Reader
+	err    error
+	opts   *options
 
-	bufferSection string
-	bufferKey     string
-	bufferValue   string
-	bufferError   string
-	buffer        string
+	file     string
+	line     int //position of the next character
+	col      int
+	text     string //current line up to the position
+	failure  *ParseError
+	failures []error
+	warnings []*ParseError //of the current line
 
-	data map[string]map[string]string
+	bufferSection  string
+	bufferKey      string
+	bufferValue    string
+	buffer         string
+	bufferList     bool
+	bufferShadowed bool
+	badSection     bool //the last section header failed, its keys are skipped
+	cr             bool //the last line ending read was \r\n
+	raw            string
+
+	data    map[string]map[string]string
+	lists   map[string]map[string][]string
+	parents map[string]string
+	layout  []item
 }
 
 // Read returns the value to a given section and key.
-// An error will be returned if a key or section does not exist.
+// For list keys the last element is returned.
+// An error wrapping ErrKeyNotFound will be returned if a key or section does not exist,
+// it also wraps ErrSectionNotFound if the section does not exist.
 func (conf *Conf) Read(section, key string) (string, error) {
-	value, exists := conf.data[section][key]
+	value, exists := conf.lookup(section, key)
+	if !exists {
+		return "", conf.notFound("read", section, key)
+	}
+	return conf.expand(section, key, value)
+}
+
+// ReadSlice returns all values to a given section and key.
+// List keys return their elements in order, other keys a single element.
+// An error will be returned if a key or section does not exist.
+func (conf *Conf) ReadSlice(section, key string) ([]string, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return nil, err
+	}
+	if !conf.isList(section, key) {
+		return []string{value}, nil
+	}
+	section, key, _ = conf.inherited(section, key)
+	list := conf.lists[section][key]
+	values := make([]string, len(list))
+	for i, elem := range list {
+		values[i], err = conf.expand(section, key, elem)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// ReadAll returns all values to a given section and key like ReadSlice,
+// or nil if the key or section does not exist.
+func (conf *Conf) ReadAll(section, key string) []string {
+	values, _ := conf.ReadSlice(section, key)
+	return values
+}
+
+// ReadDefault returns the value to a given section and key or def if the key or section does not exist
+// or the value cannot be read.
+func (conf *Conf) ReadDefault(section, key, def string) string {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// MustRead is like Read but panics if the key or section does not exist.
+func (conf *Conf) MustRead(section, key string) string {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// Map returns a copy of all sections and keys, global keys in section "".
+// Values are returned as parsed without interpolation, list keys with their last element.
+// Changes to the returned maps do not affect the conf.
+func (conf *Conf) Map() map[string]map[string]string {
+	m := make(map[string]map[string]string, len(conf.data))
+	for section, keys := range conf.data {
+		m[section] = make(map[string]string, len(keys))
+		for key, value := range keys {
+			m[section][key] = value
+		}
+	}
+	return m
+}
+
+// HasSection reports whether a section exists, it may have no keys.
+func (conf *Conf) HasSection(section string) bool {
+	_, exists := findSection(conf.data, section, conf.opts.caseInsensitive)
+	return exists
+}
+
+// HasKey reports whether a key exists in a section.
+// Unlike checking Read for an empty string, it tells a key set to an empty value (key=) from a missing one.
+func (conf *Conf) HasKey(section, key string) bool {
+	_, exists := conf.lookup(section, key)
+	return exists
+}
+
+// lookup returns the value to a given section and key and whether it exists.
+func (conf *Conf) lookup(section, key string) (string, bool) {
+	if value, overridden := conf.env(section, key); overridden {
+		return value, true
+	}
+	section, key, exists := conf.inherited(section, key)
 	if !exists {
-		return "", errors.New("read: " + conf.filename + " key \"" + key + "\" does not exist in section \"" + section + "\"")
+		return "", false
+	}
+	return conf.data[section][key], true
+}
+
+// env returns the environment variable overriding a key and whether it is set, see EnvOverrides.
+func (conf *Conf) env(section, key string) (string, bool) {
+	if conf.opts.envName == nil {
+		return "", false
+	}
+	return os.LookupEnv(conf.opts.envName(section, key))
+}
+
+// isList reports whether a key is read from a list key, which it is not if an environment variable overrides it.
+func (conf *Conf) isList(section, key string) bool {
+	if _, overridden := conf.env(section, key); overridden {
+		return false
+	}
+	section, key, _ = conf.inherited(section, key)
+	_, isList := conf.lists[section][key]
+	return isList
+}
+
+// resolve returns the stored names of a given section and key and whether the key exists.
+// Names are matched case-insensitively if enabled, the given names are returned if there is no match.
+func (conf *Conf) resolve(section, key string) (string, string, bool) {
+	section, ok := findSection(conf.data, section, conf.opts.caseInsensitive)
+	if !ok {
+		return section, key, false
+	}
+	key, ok = find(conf.data[section], key, conf.opts.caseInsensitive)
+	return section, key, ok
+}
+
+// find returns the key of m matching name, case-insensitively if fold is set.
+// If there is no match, name is returned.
+func find[V any](m map[string]V, name string, fold bool) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	if fold {
+		for key := range m {
+			if strings.EqualFold(key, name) {
+				return key, true
+			}
+		}
+	}
+	return name, false
+}
+
+// findSection is like find for section names, where a quoted subsection like [remote "Origin"]
+// is matched case-sensitively as in git.
+func findSection[V any](m map[string]V, name string, fold bool) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	if fold {
+		for section := range m {
+			if equalSection(section, name) {
+				return section, true
+			}
+		}
+	}
+	return name, false
+}
+
+// equalSection reports whether two section names are equal ignoring case except for a quoted subsection.
+func equalSection(a, b string) bool {
+	i, j := strings.IndexByte(a, '"'), strings.IndexByte(b, '"')
+	if i < 0 || j < 0 {
+		return strings.EqualFold(a, b)
 	}
-	return value, nil
+	return strings.EqualFold(a[:i], b[:j]) && a[i:] == b[j:]
 }
 
 // Open opens and parses a conf file.
-func Open(filename string) (*Conf, error) {
-	conf := &Conf{filename: filename}
+// The filename "-" parses standard input; the returned conf has no filename then.
+func Open(filename string, opts ...Option) (*Conf, error) {
+	return open(filename, opts, nil)
+}
+
+// open opens and parses a conf file included by the files in chain.
+func open(filename string, opts []Option, chain []string) (*Conf, error) {
+	if filename == "-" {
+		return Parse(os.Stdin, opts...)
+	}
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
+	conf, err := parse(file, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	conf.filename = filename
+	if err := conf.include(nil, filepath.Dir(filename), opts, append(chain, filename)); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// OpenAll opens and parses the conf files in order and merges them.
+// Keys of later files override the same keys of earlier files.
+func OpenAll(filenames ...string) (*Conf, error) {
+	conf := &Conf{data: make(map[string]map[string]string), lists: make(map[string]map[string][]string)}
+	for _, filename := range filenames {
+		other, err := Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		conf.merge(other)
+	}
+	return conf, nil
+}
+
+// OpenFS opens and parses the conf file name from fsys, files named by Includes are opened from fsys as well.
+func OpenFS(fsys fs.FS, name string, opts ...Option) (*Conf, error) {
+	return openFS(fsys, name, opts, nil)
+}
+
+// openFS opens and parses a conf file from fsys included by the files in chain.
+func openFS(fsys fs.FS, name string, opts []Option, chain []string) (*Conf, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	conf, err := parse(file, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := conf.include(fsys, path.Dir(name), opts, append(chain, name)); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// Parse parses a conf from r.
+// The include key of Includes is an error, there is no location to resolve included files against.
+func Parse(r io.Reader, opts ...Option) (*Conf, error) {
+	conf, err := parse(r, "", opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := conf.noInclude("parse"); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// parse runs the lexer on r, the file name is used for parse errors and warnings.
+func parse(r io.Reader, file string, opts []Option) (*Conf, error) {
+	conf := &Conf{opts: options{delimiters: "=", comments: defaultComments}}
+	for _, opt := range opts {
+		opt(&conf.opts)
+	}
 	state := stateStart
-	lex := &lexer{file, "", "", "", "", "", make(map[string]map[string]string)}
+	lex := &lexer{reader: bufio.NewReader(r), opts: &conf.opts, data: make(map[string]map[string]string), lists: make(map[string]map[string][]string), parents: make(map[string]string), file: file, line: 1, col: 1}
+	if lex.peek(3) == "\xef\xbb\xbf" { //skip UTF-8 byte order mark, kept for writing
+		lex.reader.Discard(3)
+		lex.raw = "\xef\xbb\xbf"
+	}
 	for {
 		switch state {
 		case stateStart:
@@ -75,30 +379,87 @@ func Open(filename string) (*Conf, error) {
 			state = lex.doKey()
 		case stateValue:
 			state = lex.doValue()
+		case stateQuoted:
+			state = lex.doQuoted()
+		case stateQuotedEnd:
+			state = lex.doQuotedEnd()
+		case stateMultiline:
+			state = lex.doMultiline()
 		case stateError:
-			return nil, lex.doError()
+			if lex.err != nil || !lex.opts.allErrors && !lex.opts.lenient {
+				return nil, lex.doError()
+			}
+			state = lex.recover()
 		case stateEOF:
+			if lex.err != nil {
+				return nil, lex.err
+			}
+			lex.endLine()
+			if len(lex.failures) > 0 {
+				return nil, errors.Join(lex.failures...)
+			}
+			lex.emit(itemText)
 			conf.data = lex.data
+			conf.lists = lex.lists
+			conf.parents = lex.parents
+			conf.layout = lex.layout
 			return conf, nil
 		}
 	}
+}
+
+// ParseString parses a conf from s.
+func ParseString(s string, opts ...Option) (*Conf, error) {
+	return Parse(strings.NewReader(s), opts...)
+}
 
+// ParseBytes parses a conf from b.
+func ParseBytes(b []byte, opts ...Option) (*Conf, error) {
+	return Parse(bytes.NewReader(b), opts...)
+}
+
+// MustParse is like ParseBytes but panics if b cannot be parsed.
+// It simplifies initialization of package-level variables holding embedded default confs.
+func MustParse(b []byte, opts ...Option) *Conf {
+	conf, err := ParseBytes(b, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return conf
 }
 
 func (lex *lexer) doStart() int {
-	switch lex.add() {
+	switch lex.look() {
 	case "":
 		return stateEOF
 	case " ", "	", "\n":
+		lex.add()
 		return stateStart
 	case "[":
+		lex.emit(itemText)
+		lex.add()
 		lex.flush()
+		lex.badSection = true
 		return stateSection
-	case "#", ";":
+	}
+	if lex.comment() {
+		lex.add()
 		return stateComment
 	}
-	lex.bufferError = "key not in section: " + lex.buffer
-	return stateError
+	if lex.badSection && lex.opts.lenient {
+		return lex.fail("key after invalid section header is skipped")
+	} else if lex.badSection {
+		return lex.skip(stateStart)
+	}
+	if lex.opts.strict && !lex.opts.globalKeys {
+		return lex.fail("key outside of section")
+	}
+	if _, ok := lex.data[""]; !ok { //global keys before the first section
+		lex.data[""] = make(map[string]string)
+	}
+	lex.emit(itemText)
+	lex.flush()
+	return stateKey
 }
 
 func (lex *lexer) doMid() int {
@@ -109,13 +470,22 @@ func (lex *lexer) doMid() int {
 		lex.add()
 		return stateMid
 	case "[":
+		lex.emit(itemText)
 		lex.add()
 		lex.flush()
+		lex.badSection = true
 		return stateSection
-	case "#", ";":
+	}
+	if lex.comment() {
 		lex.add()
 		return stateComment
 	}
+	if lex.badSection && lex.opts.lenient {
+		return lex.fail("key after invalid section header is skipped")
+	} else if lex.badSection {
+		return lex.skip(stateMid)
+	}
+	lex.emit(itemText)
 	lex.flush()
 	return stateKey
 }
@@ -136,18 +506,31 @@ func (lex *lexer) doComment() int {
 func (lex *lexer) doSection() int {
 	switch lex.look() {
 	case "\n", "":
-		lex.add()
-		lex.bufferError = "broken section name: " + lex.buffer
-		return stateError
+		return lex.fail("broken section name: " + lex.buffer)
 	case "]":
-		lex.bufferSection = lex.flush()
-		
-		if _, ok := lex.data[lex.bufferSection]; ok {
-			lex.bufferError = "duplicate section: " + lex.bufferSection
-			return stateError
+		lex.bufferSection = strings.Trim(lex.flush(), " 	")
+		parent := ""
+		if child, name, ok := strings.Cut(lex.bufferSection, ":"); ok && lex.opts.inheritance { //[child : parent]
+			lex.bufferSection, parent = strings.TrimRight(child, " 	"), strings.TrimLeft(name, " 	")
+		}
+		if lex.bufferSection == "" && lex.opts.strict {
+			return lex.fail("empty section name")
+		}
+
+		if name, ok := findSection(lex.data, lex.bufferSection, lex.opts.caseInsensitive); ok {
+			if !lex.opts.mergeSections {
+				return lex.failWith(&DuplicateSectionError{lex.bufferSection, lex.line})
+			}
+			lex.bufferSection = name
+		} else {
+			lex.data[lex.bufferSection] = make(map[string]string)
+		}
+		if parent != "" {
+			lex.parents[lex.bufferSection] = parent
 		}
-		lex.data[lex.bufferSection] = make(map[string]string)
+		lex.badSection = false
 		lex.add()
+		lex.emit(itemSection)
 		return stateMid
 	}
 	lex.add()
@@ -155,67 +538,365 @@ func (lex *lexer) doSection() int {
 }
 
 func (lex *lexer) doKey() int {
-	switch lex.look() {
-	case "\n", "":
+	switch chr := lex.look(); {
+	case (chr == "\n" || chr == "") && lex.opts.valuelessKeys && strings.TrimSpace(lex.buffer) != "":
+		if !lex.key() {
+			return stateError
+		}
+		lex.bufferValue = ""
 		lex.add()
-		lex.bufferError = "broken key name: " + lex.buffer
-		return stateError
-	case "=":
-		lex.bufferKey = lex.flush()
-		if _, ok := lex.data[lex.bufferSection][lex.bufferKey]; ok {
-			lex.bufferError = "duplicate key in section: " + lex.bufferKey
+		lex.store()
+		return stateMid
+	case chr == "\n" || chr == "":
+		return lex.fail("broken key name: " + lex.buffer)
+	case strings.Contains(lex.opts.delimiters, chr):
+		if !lex.key() {
 			return stateError
 		}
 		lex.add()
+		spaced := false
+		for lex.look() == " " || lex.look() == "	" {
+			lex.add()
+			spaced = true
+		}
 		lex.flush()
+		if lex.opts.systemd || lex.opts.windows { //quotes are part of the value or stripped without escapes
+			return stateValue
+		}
+		if spaced && lex.comment() { //empty value followed by an inline comment
+			lex.bufferValue = ""
+			lex.storeInline()
+			lex.add()
+			return stateComment
+		}
+		if lex.peek(3) == "\"\"\"" {
+			lex.add()
+			lex.add()
+			lex.add()
+			if lex.look() == "\n" {
+				lex.add()
+			}
+			lex.flush()
+			return stateMultiline
+		}
+		if lex.look() == "\"" {
+			lex.add()
+			lex.flush()
+			return stateQuoted
+		}
 		return stateValue
 	}
 	lex.add()
 	return stateKey
 }
 
+// key takes the buffered key name and applies the duplicate key policy.
+// It reports false and sets the error if the key is a forbidden duplicate.
+func (lex *lexer) key() bool {
+	lex.bufferKey, lex.bufferList = strings.CutSuffix(strings.TrimRight(lex.flush(), " 	"), "[]")
+	lex.bufferKey = strings.TrimRight(lex.bufferKey, " 	")
+	lex.bufferShadowed = false
+	name, ok := find(lex.data[lex.bufferSection], lex.bufferKey, lex.opts.caseInsensitive)
+	if !ok {
+		return true
+	}
+	value := lex.data[lex.bufferSection][name]
+	_, isList := lex.lists[lex.bufferSection][name]
+	switch {
+	case lex.bufferList && isList:
+	case lex.opts.systemd && value == "" && !isList: //replaces an empty assignment
+		lex.shadow(name)
+	case lex.opts.duplicateKeys == DuplicateCollect:
+		if !isList {
+			lex.list()[name] = []string{value}
+		}
+	case lex.opts.duplicateKeys == DuplicateFirst:
+		lex.warn("duplicate key is ignored: " + lex.bufferKey)
+		lex.bufferShadowed = true
+	case lex.opts.duplicateKeys == DuplicateLast:
+		lex.warn("duplicate key overrides earlier value: " + lex.bufferKey)
+		delete(lex.lists[lex.bufferSection], name)
+		lex.shadow(name)
+	default:
+		lex.failWith(&DuplicateKeyError{lex.bufferSection, lex.bufferKey, lex.line})
+		return false
+	}
+	lex.bufferKey = name
+	return true
+}
+
 func (lex *lexer) doValue() int {
 	switch lex.look() {
-	case "\n", "":
-		lex.bufferValue = lex.flush()
+	case "\n":
+		if strings.HasSuffix(lex.buffer, "\\") && !lex.opts.windows { //line continuation
+			lex.add()
+			lex.buffer = lex.buffer[:len(lex.buffer)-2]
+			if lex.opts.systemd {
+				lex.buffer += " "
+			}
+			return stateValue
+		}
+		fallthrough
+	case "":
+		lex.bufferValue = strings.TrimRight(lex.flush(), " 	")
+		if lex.opts.windows && len(lex.bufferValue) >= 2 && strings.HasPrefix(lex.bufferValue, "\"") && strings.HasSuffix(lex.bufferValue, "\"") {
+			lex.bufferValue = lex.bufferValue[1 : len(lex.bufferValue)-1]
+		}
 		lex.add()
-		lex.data[lex.bufferSection][lex.bufferKey] = lex.bufferValue
+		lex.store()
 		return stateMid
 	}
+	if (strings.HasSuffix(lex.buffer, " ") || strings.HasSuffix(lex.buffer, "	")) && !lex.opts.systemd && !lex.opts.windows && lex.comment() { //inline comment
+		lex.bufferValue = strings.TrimRight(lex.flush(), " 	")
+		lex.storeInline()
+		lex.add()
+		return stateComment
+	}
 	lex.add()
 	return stateValue
 }
 
+func (lex *lexer) doQuoted() int {
+	switch lex.look() {
+	case "\n", "":
+		return lex.fail("unterminated quoted value: " + lex.buffer)
+	case "\"":
+		lex.bufferValue = lex.flush()
+		lex.add()
+		lex.flush()
+		return stateQuotedEnd
+	case "\\":
+		lex.add()
+		chr := lex.add()
+		escaped, ok := escapes[chr]
+		if !ok {
+			return lex.fail("invalid escape sequence in value of key " + lex.bufferKey + ": \\" + chr)
+		}
+		lex.buffer = lex.buffer[:len(lex.buffer)-2] + escaped
+		return stateQuoted
+	}
+	lex.add()
+	return stateQuoted
+}
+
+func (lex *lexer) doQuotedEnd() int {
+	switch lex.look() {
+	case " ", "	":
+		lex.add()
+		return stateQuotedEnd
+	case "\n", "":
+		lex.add()
+		lex.flush()
+		lex.store()
+		return stateMid
+	}
+	if lex.comment() {
+		lex.storeInline()
+		lex.add()
+		return stateComment
+	}
+	return lex.fail("text after quoted value of key: " + lex.bufferKey)
+}
+
+func (lex *lexer) doMultiline() int {
+	if lex.peek(3) == "\"\"\"" {
+		lex.bufferValue = lex.flush()
+		lex.add()
+		lex.add()
+		lex.add()
+		lex.flush()
+		return stateQuotedEnd
+	}
+	if lex.add() == "" {
+		return lex.fail("unterminated multi-line value of key: " + lex.bufferKey)
+	}
+	return stateMultiline
+}
+
 func (lex *lexer) doError() error {
-	return errors.New(lex.bufferError)
+	if lex.err != nil {
+		return lex.err
+	}
+	return lex.failure
+}
+
+// fail records a parse error at the current position and returns the error state.
+// The rest of the line is read for the snippet of the error.
+func (lex *lexer) fail(msg string) int {
+	lex.failure = &ParseError{File: lex.file, Line: lex.line, Col: lex.col, Msg: msg}
+	for chr := lex.look(); chr != "\n" && chr != ""; chr = lex.look() {
+		lex.add()
+	}
+	lex.failure.Snippet = lex.text
+	return stateError
+}
+
+// skip drops the rest of the line and continues with state, e.g. for keys after an invalid section header
+// while collecting all errors, which only the header is reported for.
+func (lex *lexer) skip(state int) int {
+	for chr := lex.look(); chr != "\n" && chr != ""; chr = lex.look() {
+		lex.add()
+	}
+	lex.flush()
+	lex.raw = ""
+	return state
+}
+
+// failWith is like fail for an error that is wrapped by the parse error.
+func (lex *lexer) failWith(err error) int {
+	lex.fail(err.Error())
+	lex.failure.Err = err
+	return stateError
+}
+
+// recover records the parse error and continues with the next line.
+// In lenient mode the error is reported as a warning and the line is kept as text for writing.
+func (lex *lexer) recover() int {
+	lex.flush()
+	if lex.opts.lenient {
+		if lex.opts.warn != nil {
+			lex.warnings = append(lex.warnings, lex.failure)
+		}
+		lex.emit(itemText)
+	} else {
+		lex.failures = append(lex.failures, lex.failure)
+		lex.raw = ""
+	}
+	if lex.bufferSection == "" {
+		return stateStart
+	}
+	return stateMid
 }
 
 func (lex *lexer) get() string {
-	chr := make([]byte, 1)
-	_, err := io.ReadFull(lex.file, chr)
-	if err != nil {
-		return ""
+	chr := lex.next()
+	lex.reader.Discard(len(chr))
+	lex.cr = false
+	if chr == "\r" && lex.next() == "\n" {	//\r\n to \n for easier parsing, add keeps the \r for writing
+		lex.get()
+		lex.cr = true
+		return "\n"
 	}
-	if string(chr[0]) == "\r" && lex.look() == "\n"  {	//\r\n to \n for easier parsing
-		return lex.get()
+	switch chr {
+	case "":
+	case "\n":
+		lex.endLine()
+		lex.line, lex.col, lex.text = lex.line+1, 1, ""
+	default:
+		lex.check(chr)
+		lex.col++
+		lex.text += chr
 	}
-	return string(chr[0])
+	return chr
 }
 
 func (lex *lexer) add() string {
 	chr := lex.get()
 	lex.buffer += chr
+	if lex.cr {
+		lex.raw += "\r"
+	}
+	lex.raw += chr
 	return chr
 }
 
 func (lex *lexer) look() string {
-	chr := lex.get()
-	lex.file.Seek(-1, 1)
+	chr := lex.next()
+	if chr == "\r" && lex.peek(2) == "\r\n" {
+		return "\n"
+	}
 	return chr
 }
 
+// next returns the next UTF-8 encoded rune without consuming it.
+// Bytes that are not valid UTF-8 are returned one at a time.
+func (lex *lexer) next() string {
+	chr, err := lex.reader.Peek(utf8.UTFMax)
+	if len(chr) == 0 {
+		if err != io.EOF {
+			lex.err = &ParseError{File: lex.file, Line: lex.line, Col: lex.col, Msg: err.Error(), Err: err}
+		}
+		return ""
+	}
+	_, size := utf8.DecodeRune(chr)
+	return string(chr[:size])
+}
+
+// comment reports whether a comment prefix follows.
+func (lex *lexer) comment() bool {
+	for _, prefix := range lex.opts.comments {
+		if prefix != "" && lex.peek(len(prefix)) == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// peek returns up to the next n bytes without consuming them.
+func (lex *lexer) peek(n int) string {
+	chr, _ := lex.reader.Peek(n)
+	return string(chr)
+}
+
 func (lex *lexer) flush() string {
 	save := lex.buffer
 	lex.buffer = ""
 	return save
 }
+
+// store sets the buffered key to the buffered value and records it.
+func (lex *lexer) store() {
+	if lex.bufferShadowed {
+		lex.emit(itemKey)
+		return
+	}
+	if lex.opts.systemd && lex.bufferValue == "" { //an empty assignment resets the key
+		delete(lex.lists[lex.bufferSection], lex.bufferKey)
+		lex.shadow(lex.bufferKey)
+	}
+	lex.data[lex.bufferSection][lex.bufferKey] = lex.bufferValue
+	if _, isList := lex.lists[lex.bufferSection][lex.bufferKey]; isList || lex.bufferList {
+		lists := lex.list()
+		lists[lex.bufferKey] = append(lists[lex.bufferKey], lex.bufferValue)
+	}
+	lex.emit(itemKey)
+}
+
+// shadow marks the recorded items of a key of the buffered section as overridden by a later duplicate.
+func (lex *lexer) shadow(key string) {
+	for i, it := range lex.layout {
+		if it.kind == itemKey && it.section == lex.bufferSection && it.key == key {
+			lex.layout[i].shadowed = true
+		}
+	}
+}
+
+// list returns the lists of the buffered section, creating them if necessary.
+func (lex *lexer) list() map[string][]string {
+	if lex.lists[lex.bufferSection] == nil {
+		lex.lists[lex.bufferSection] = make(map[string][]string)
+	}
+	return lex.lists[lex.bufferSection]
+}
+
+// storeInline is like store but leaves the whitespace preceding an inline comment out of the recorded key.
+func (lex *lexer) storeInline() {
+	text := strings.TrimRight(lex.raw, " 	")
+	space := lex.raw[len(text):]
+	lex.raw = text
+	lex.store()
+	lex.raw = space
+}
+
+// emit records the raw text read since the last call as an item of the given kind.
+func (lex *lexer) emit(kind int) {
+	if kind == itemText && lex.raw == "" {
+		return
+	}
+	it := item{kind: kind, section: lex.bufferSection, text: lex.raw, line: lex.line - strings.Count(lex.raw, "\n")}
+	if kind == itemKey {
+		it.key, it.list, it.shadowed, it.value = lex.bufferKey, lex.bufferList, lex.bufferShadowed, lex.bufferValue
+	}
+	lex.layout = append(lex.layout, it)
+	lex.raw = ""
+}
@@ -0,0 +1,65 @@
+package conf
+
+import "testing"
+
+func TestParseValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		key   string
+		want  string
+	}{
+		{"plain", "[s]\nk=v\n", "k", "v"},
+		{"spaces", "[s]\nk = v w \n", "k", "v w"},
+		{"inline comment", "[s]\nk=v ; c\n", "k", "v"},
+		{"empty", "[s]\nk=\n", "k", ""},
+		{"empty semicolon comment", "[s]\nk= ; comment\n", "k", ""},
+		{"empty hash comment", "[s]\nk=   # c\n", "k", ""},
+		{"crlf", "[s]\r\nk=v\r\n", "k", "v"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := ParseString(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := conf.Read("s", tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Read = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLenientSkipsKeysAfterBadHeader(t *testing.T) {
+	var warnings []*ParseError
+	conf, err := ParseString("[a]\nk=1\n[b\nx=2\ny=3\n[c]\nz=4\n", Lenient(), Warnings(func(w *ParseError) {
+		warnings = append(warnings, w)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 3 {
+		t.Errorf("got %d warnings, want 3", len(warnings))
+	}
+	if conf.HasKey("a", "x") || conf.HasKey("a", "y") {
+		t.Error("keys after a malformed header were read into the previous section")
+	}
+	if got := conf.ReadDefault("c", "z", ""); got != "4" {
+		t.Errorf("c.z = %q, want %q", got, "4")
+	}
+}
+
+func TestAllErrorsBadHeader(t *testing.T) {
+	_, err := ParseString("[a]\n[b\nx=1\ny=2\n[c]\nz\n", AllErrors())
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("ParseString error = %v, want joined errors", err)
+	}
+	if errs := joined.Unwrap(); len(errs) != 2 {
+		t.Errorf("got %d errors, want 2 for the header and z: %v", len(errs), err)
+	}
+}
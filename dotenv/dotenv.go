@@ -0,0 +1,115 @@
+// Package dotenv reads .env files into a section of a conf:
+//
+//	# comment
+//	export NAME=value exported like in a shell script
+//	PORT = 8080 # inline comment
+//	GREETING="double quoted with \"escapes\"\n"
+//	RAW='single quoted without escapes'
+//	CERT="quoted values
+//	may span lines"
+//
+// Variables are not expanded.
+package dotenv
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/hirsch/conf"
+)
+
+// FromDotenv builds a conf from a .env file with all variables as keys of section, "" for global keys.
+// Later assignments of a variable override earlier ones.
+func FromDotenv(b []byte, section string) (*conf.Conf, error) {
+	lines := strings.Split(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+	keys := make(map[string]string)
+	for i := 0; i < len(lines); i++ {
+		num := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "export"); ok && (strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, "\t")) {
+			line = strings.TrimLeft(rest, " \t")
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, syntaxError(num, "missing = after variable name")
+		}
+		if name = strings.TrimRight(name, " \t"); !isName(name) {
+			return nil, syntaxError(num, "invalid variable name \""+name+"\"")
+		}
+		value = strings.TrimLeft(value, " \t")
+
+		if !strings.HasPrefix(value, "\"") && !strings.HasPrefix(value, "'") {
+			for j := 0; j < len(value); j++ { //an inline comment follows whitespace, which was trimmed before the value
+				if value[j] == '#' && (j == 0 || value[j-1] == ' ' || value[j-1] == '\t') {
+					value = value[:j]
+					break
+				}
+			}
+			keys[name] = strings.TrimSpace(value)
+			continue
+		}
+
+		// A quoted value ends at the matching quote, which may be on a later line.
+		quote, text := value[0], value[1:]
+		end := closing(text, quote)
+		for end < 0 {
+			if i+1 == len(lines) {
+				return nil, syntaxError(num, "unterminated quoted value of "+name)
+			}
+			i++
+			text += "\n" + lines[i]
+			end = closing(text, quote)
+		}
+		if rest := strings.TrimSpace(text[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, syntaxError(i+1, "text after quoted value of "+name)
+		}
+		value = text[:end]
+		if quote == '"' {
+			value = escapes.Replace(value)
+		}
+		keys[name] = value
+	}
+
+	b, err := json.Marshal(map[string]map[string]string{section: keys})
+	if err != nil {
+		return nil, err
+	}
+	return conf.FromJSON(b)
+}
+
+// escapes replaces the escape sequences of double quoted values, other backslashes are kept.
+var escapes = strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, "\"", `\\`, "\\", `\$`, "$")
+
+// closing returns the index of the quote ending a quoted value in text or -1.
+// Double quotes may be escaped with a backslash.
+func closing(text string, quote byte) int {
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && quote == '"':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// isName reports whether name is a valid variable name, dots and dashes are accepted after the first character.
+func isName(name string) bool {
+	for i, chr := range name {
+		if chr != '_' && (chr < 'A' || chr > 'Z') && (chr < 'a' || chr > 'z') && (i == 0 || (chr < '0' || chr > '9') && chr != '.' && chr != '-') {
+			return false
+		}
+	}
+	return name != ""
+}
+
+// syntaxError returns an error for a line of a .env file.
+func syntaxError(num int, msg string) error {
+	return errors.New("fromdotenv: line " + strconv.Itoa(num) + ": " + msg)
+}
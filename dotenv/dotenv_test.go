@@ -0,0 +1,49 @@
+package dotenv
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFromDotenv(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]string
+	}{
+		{"plain", "A=1\nB = two words\n", map[string]string{"A": "1", "B": "two words"}},
+		{"export", "export A=1\n", map[string]string{"A": "1"}},
+		{"comments", "# c\nA=1 # c\nB=x#y\n", map[string]string{"A": "1", "B": "x#y"}},
+		{"empty with comment", "A= # c\nB=#c\n", map[string]string{"A": "", "B": ""}},
+		{"double quoted", `A="a \"b\"\n"` + "\n", map[string]string{"A": "a \"b\"\n"}},
+		{"single quoted", `A='a\n # b'` + "\n", map[string]string{"A": `a\n # b`}},
+		{"multi-line", "A=\"x\ny\"\n", map[string]string{"A": "x\ny"}},
+		{"crlf", "A=1\r\nB=2\r\n", map[string]string{"A": "1", "B": "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := FromDotenv([]byte(tt.input), "env")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Map()["env"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromDotenv(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromDotenvRoundTrip(t *testing.T) {
+	c, err := FromDotenv([]byte("A=1\nB=\"two words\"\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FromDotenv([]byte(strings.Join(c.ToEnv(""), "\n")), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Map(), c.Map()) {
+		t.Errorf("round trip = %q, want %q", got.Map(), c.Map())
+	}
+}
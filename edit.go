@@ -0,0 +1,165 @@
+package conf
+
+import "errors"
+
+// Set sets the value to a given section and key.
+// The section is created if it does not exist and an existing value or list is overwritten.
+// Names that cannot be written back, e.g. a key containing the delimiter, are reported by WriteTo.
+func (conf *Conf) Set(section, key, value string) {
+	if conf.data == nil {
+		conf.data = make(map[string]map[string]string)
+	}
+	section, key, _ = conf.resolve(section, key)
+	if _, exists := conf.data[section]; !exists {
+		conf.data[section] = make(map[string]string)
+	}
+	conf.data[section][key] = value
+	delete(conf.lists[section], key)
+}
+
+// Delete removes a key from a section and reports whether it existed.
+func (conf *Conf) Delete(section, key string) bool {
+	section, key, exists := conf.resolve(section, key)
+	if !exists {
+		return false
+	}
+	delete(conf.data[section], key)
+	delete(conf.lists[section], key)
+	return true
+}
+
+// DeleteSection removes a section and all of its keys and reports whether it existed.
+func (conf *Conf) DeleteSection(section string) bool {
+	section, exists := findSection(conf.data, section, conf.opts.caseInsensitive)
+	if !exists {
+		return false
+	}
+	delete(conf.data, section)
+	delete(conf.lists, section)
+	delete(conf.parents, section)
+	return true
+}
+
+// AddSection creates an empty section.
+// An error will be returned if the section already exists or its name cannot be written as a header,
+// e.g. if it contains a bracket or a line break.
+func (conf *Conf) AddSection(section string) error {
+	if msg := conf.invalidSection(section); msg != "" {
+		return errors.New(at("addsection", conf.filename) + " section name \"" + section + "\" " + msg)
+	}
+	if _, exists := findSection(conf.data, section, conf.opts.caseInsensitive); exists {
+		return errors.New(at("addsection", conf.filename) + " duplicate section: " + section)
+	}
+	if conf.data == nil {
+		conf.data = make(map[string]map[string]string)
+	}
+	conf.data[section] = make(map[string]string)
+	return nil
+}
+
+// RenameSection renames a section keeping its keys.
+// An error will be returned if the section does not exist, a section with the new name already exists
+// or the new name cannot be written as a header.
+func (conf *Conf) RenameSection(section, name string) error {
+	if msg := conf.invalidSection(name); msg != "" {
+		return errors.New(at("renamesection", conf.filename) + " section name \"" + name + "\" " + msg)
+	}
+	section, exists := findSection(conf.data, section, conf.opts.caseInsensitive)
+	if !exists {
+		return &notFoundError{at("renamesection", conf.filename) + " section \"" + section + "\" does not exist", []error{ErrSectionNotFound}}
+	}
+	if section == name {
+		return nil
+	}
+	if other, exists := findSection(conf.data, name, conf.opts.caseInsensitive); exists && other != section {
+		return errors.New(at("renamesection", conf.filename) + " duplicate section: " + name)
+	}
+	keys := conf.data[section]
+	delete(conf.data, section)
+	conf.data[name] = keys
+	if lists, exists := conf.lists[section]; exists {
+		delete(conf.lists, section)
+		conf.lists[name] = lists
+	}
+	if parent, exists := conf.parents[section]; exists {
+		delete(conf.parents, section)
+		conf.parents[name] = parent
+	}
+	renamed := map[string]bool{name: true}
+	for child, parent := range conf.parents {
+		if parent, _ := findSection(conf.data, parent, conf.opts.caseInsensitive); parent == section {
+			conf.parents[child] = name
+			renamed[child] = true
+		}
+	}
+	for i, it := range conf.layout {
+		if it.section == section {
+			conf.layout[i].section = name
+		}
+		if it.kind == itemSection && renamed[conf.layout[i].section] {
+			conf.layout[i].text = conf.header(conf.layout[i].section)
+		}
+	}
+	return nil
+}
+
+// RenameKey renames a key within a section keeping its value.
+// An error will be returned if the key or section does not exist, the new key already exists in the section
+// or the new name cannot be written as a key, e.g. if it contains a delimiter.
+func (conf *Conf) RenameKey(section, key, name string) error {
+	if msg := conf.invalidKey(name); msg != "" {
+		return errors.New(at("renamekey", conf.filename) + " key name \"" + name + "\" " + msg)
+	}
+	section, key, exists := conf.resolve(section, key)
+	if !exists {
+		return conf.notFound("renamekey", section, key)
+	}
+	if key == name {
+		return nil
+	}
+	if other, exists := find(conf.data[section], name, conf.opts.caseInsensitive); exists && other != key {
+		return errors.New(at("renamekey", conf.filename) + " duplicate key in section: " + name)
+	}
+	value := conf.data[section][key]
+	delete(conf.data[section], key)
+	conf.data[section][name] = value
+	if list, exists := conf.lists[section][key]; exists {
+		delete(conf.lists[section], key)
+		conf.lists[section][name] = list
+	}
+	for i, it := range conf.layout {
+		if it.kind == itemKey && it.section == section && it.key == key {
+			conf.layout[i].key = name
+			conf.layout[i].text = conf.rewrite(it, name, it.value)
+		}
+	}
+	return nil
+}
+
+// merge copies all sections and keys of other into conf, overwriting existing values.
+func (conf *Conf) merge(other *Conf) {
+	for name, keys := range other.data {
+		section, exists := findSection(conf.data, name, conf.opts.caseInsensitive)
+		if !exists {
+			conf.data[section] = make(map[string]string)
+		}
+		for key, value := range keys {
+			list, isList := other.lists[name][key]
+			key, _ = find(conf.data[section], key, conf.opts.caseInsensitive)
+			conf.data[section][key] = value
+			delete(conf.lists[section], key)
+			if isList {
+				if conf.lists[section] == nil {
+					conf.lists[section] = make(map[string][]string)
+				}
+				conf.lists[section][key] = append([]string(nil), list...)
+			}
+		}
+		if parent, exists := other.parents[name]; exists {
+			if conf.parents == nil {
+				conf.parents = make(map[string]string)
+			}
+			conf.parents[section] = parent
+		}
+	}
+}
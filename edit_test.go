@@ -0,0 +1,50 @@
+package conf
+
+import "testing"
+
+func TestEditInvalidNames(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(conf *Conf) error
+	}{
+		{"add section bracket", func(conf *Conf) error { return conf.AddSection("a]b") }},
+		{"add section line break", func(conf *Conf) error { return conf.AddSection("a\nb") }},
+		{"add section whitespace", func(conf *Conf) error { return conf.AddSection(" a") }},
+		{"rename section bracket", func(conf *Conf) error { return conf.RenameSection("s", "[t]") }},
+		{"rename key delimiter", func(conf *Conf) error { return conf.RenameKey("s", "k", "a=b") }},
+		{"rename key section", func(conf *Conf) error { return conf.RenameKey("s", "k", "[k]") }},
+		{"rename key comment", func(conf *Conf) error { return conf.RenameKey("s", "k", ";k") }},
+		{"rename key empty", func(conf *Conf) error { return conf.RenameKey("s", "k", "") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := ParseString("[s]\nk=v\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.edit(conf); err == nil {
+				t.Error("edit returned no error")
+			}
+		})
+	}
+}
+
+func TestEditValidNames(t *testing.T) {
+	conf, err := ParseString("[s]\nk=v\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.AddSection(`remote "a b"`); err != nil {
+		t.Error(err)
+	}
+	if err := conf.RenameSection("s", "t.u"); err != nil {
+		t.Error(err)
+	}
+	if err := conf.RenameKey("t.u", "k", "k2"); err != nil {
+		t.Error(err)
+	}
+	want := "[remote \"a b\"]\n\n[t.u]\nk2=v\n"
+	if got := conf.String(); got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,41 @@
+package conf
+
+import "strings"
+
+// ToEnv returns the keys as environment variable assignments like NAME=value, e.g. for exec.Cmd.Env.
+// Variables are named like for Layers.Env and EnvOverrides, list keys are joined with commas.
+// Keys are in the order of Walk, keys whose value cannot be read are left out.
+func (conf *Conf) ToEnv(prefix string) []string {
+	env := []string{}
+	for _, section := range append([]string{""}, conf.Sections()...) {
+		keys, _ := conf.Keys(section)
+		for _, key := range keys {
+			values, err := conf.ReadSlice(section, key)
+			if err != nil {
+				continue
+			}
+			env = append(env, envVar(prefix, section, key)+"="+strings.Join(values, ","))
+		}
+	}
+	return env
+}
+
+// envVar returns the name of the environment variable of a key, see Layers.Env.
+func envVar(prefix, section, key string) string {
+	name := key
+	if section != "" {
+		name = section + "_" + name
+	}
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z':
+			return r - 'a' + 'A'
+		case 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,51 @@
+package conf
+
+import "testing"
+
+func TestEnvOverrides(t *testing.T) {
+	t.Setenv("APP_SERVER_PORT", "9090")
+	t.Setenv("APP_SERVER_HOST_NAME", "example.com")
+	t.Setenv("APP_DEBUG", "on")
+	t.Setenv("APP_SERVER_TAGS", "x")
+	conf, err := ParseString("debug=off\n[server]\nport=8080\ntimeout=5s\ntags[]=a\ntags[]=b\n", EnvOverrides("app"), AllowGlobalKeys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		section string
+		key     string
+		want    string
+	}{
+		{"server", "port", "9090"},
+		{"server", "timeout", "5s"},
+		{"server", "host-name", "example.com"},
+		{"", "debug", "on"},
+	}
+	for _, tt := range tests {
+		if got, err := conf.Read(tt.section, tt.key); err != nil || got != tt.want {
+			t.Errorf("Read(%q, %q) = %q, %v, want %q", tt.section, tt.key, got, err, tt.want)
+		}
+	}
+	if got, err := conf.ReadSlice("server", "tags"); err != nil || len(got) != 1 || got[0] != "x" {
+		t.Errorf("ReadSlice(server, tags) = %q, %v, want the variable", got, err)
+	}
+	if got := conf.Map()["server"]["port"]; got != "8080" {
+		t.Errorf("Map()[server][port] = %q, want the value of the file", got)
+	}
+}
+
+func TestEnvOverridesFunc(t *testing.T) {
+	t.Setenv("PORT", "9090")
+	conf, err := ParseString("[server]\nport=8080\n", EnvOverridesFunc(func(section, key string) string {
+		if section == "server" && key == "port" {
+			return "PORT"
+		}
+		return ""
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := conf.ReadInt("server", "port"); err != nil || got != 9090 {
+		t.Errorf("ReadInt(server, port) = %v, %v, want 9090", got, err)
+	}
+}
@@ -0,0 +1,113 @@
+package conf
+
+import (
+	"errors"
+	"strconv"
+)
+
+var (
+	// ErrKeyNotFound is wrapped by the errors returned for keys that do not exist,
+	// test for it with errors.Is.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrSectionNotFound is wrapped by the errors returned for sections that do not exist.
+	// Reading a key of a missing section wraps both errors.
+	ErrSectionNotFound = errors.New("section not found")
+)
+
+// notFoundError is an error message wrapping ErrKeyNotFound or ErrSectionNotFound.
+type notFoundError struct {
+	msg  string
+	errs []error
+}
+
+func (err *notFoundError) Error() string {
+	return err.msg
+}
+
+func (err *notFoundError) Unwrap() []error {
+	return err.errs
+}
+
+// notFound returns the error of op for a missing key.
+// It names the section instead if that is missing as well and wraps ErrSectionNotFound then.
+func (conf *Conf) notFound(op, section, key string) error {
+	section, exists := findSection(conf.data, section, conf.opts.caseInsensitive)
+	if !exists {
+		return &notFoundError{at(op, conf.filename) + " section \"" + section + "\" of key \"" + key + "\" does not exist", []error{ErrSectionNotFound, ErrKeyNotFound}}
+	}
+	return &notFoundError{at(op, conf.where(section, "")) + " key \"" + key + "\" does not exist in section \"" + section + "\"", []error{ErrKeyNotFound}}
+}
+
+// at starts an error message with op and the location from conf.filename or where,
+// an empty location is left out for confs without a filename.
+func at(op, location string) string {
+	if location == "" {
+		return op + ":"
+	}
+	return op + ": " + location
+}
+
+// where returns the file name and line of a key for error messages, or of the section header if key is empty.
+// The line is left out if it is unknown because the key was not parsed.
+func (conf *Conf) where(section, key string) string {
+	kind := itemSection
+	if key != "" {
+		kind = itemKey
+		section, key, _ = conf.inherited(section, key)
+	}
+	for _, it := range conf.layout {
+		if it.kind != kind || it.section != section || it.key != key || it.shadowed {
+			continue
+		}
+		if conf.filename == "" {
+			return "line " + strconv.Itoa(it.line)
+		}
+		return conf.filename + ":" + strconv.Itoa(it.line)
+	}
+	return conf.filename
+}
+
+// ParseError is returned if a conf cannot be parsed.
+// Line and Col are counted from 1, Col in characters; Snippet is the offending line.
+// Err is a more specific error like *DuplicateKeyError if there is one, it is returned by Unwrap.
+type ParseError struct {
+	File    string
+	Line    int
+	Col     int
+	Msg     string
+	Snippet string
+	Err     error
+}
+
+func (err *ParseError) Error() string {
+	pos := strconv.Itoa(err.Line) + ":" + strconv.Itoa(err.Col) + ": " + err.Msg
+	if err.File == "" {
+		return pos
+	}
+	return err.File + ":" + pos
+}
+
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// DuplicateSectionError is wrapped by the ParseError for a section appearing twice, see MergeSections.
+type DuplicateSectionError struct {
+	Name string
+	Line int
+}
+
+func (err *DuplicateSectionError) Error() string {
+	return "duplicate section: " + err.Name
+}
+
+// DuplicateKeyError is wrapped by the ParseError for a key appearing twice in a section, see DuplicateKeys.
+type DuplicateKeyError struct {
+	Section string
+	Key     string
+	Line    int
+}
+
+func (err *DuplicateKeyError) Error() string {
+	return "duplicate key in section: " + err.Key
+}
@@ -0,0 +1,34 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestErrorsWithoutFilename(t *testing.T) {
+	conf, err := ParseString("[a]\nx=${b.y}\n[b]\ny=${a.x}\n", Interpolate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"missing section", func() error { _, err := conf.Read("c", "k"); return err }()},
+		{"missing key", func() error { _, err := conf.Read("a", "k"); return err }()},
+		{"cycle", func() error { _, err := conf.Read("a", "x"); return err }()},
+		{"add section", conf.AddSection("a")},
+		{"rename key", conf.RenameKey("a", "x", "y\n")},
+		{"keys", func() error { _, err := conf.Keys("c"); return err }()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err == nil {
+				t.Fatal("no error")
+			}
+			if msg := tt.err.Error(); strings.Contains(msg, "  ") {
+				t.Errorf("error %q has a double space", msg)
+			}
+		})
+	}
+}
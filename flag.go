@@ -0,0 +1,40 @@
+package conf
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+)
+
+// BindFlags sets the flags of fs that were not passed on the command line to the keys of the same name in section,
+// which gives file-backed defaults for flags. Call it after fs.Parse.
+// List keys set a flag once per element, so repeatable flags collect all elements.
+// Boolean flags accept the values of ReadBool like yes or off.
+// Flags without a key keep their default. An error will be returned if a value is rejected by its flag.
+func (conf *Conf) BindFlags(fs *flag.FlagSet, section string) error {
+	passed := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		passed[f.Name] = true
+	})
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || passed[f.Name] || !conf.HasKey(section, f.Name) {
+			return
+		}
+		var values []string
+		if values, err = conf.ReadSlice(section, f.Name); err != nil {
+			return
+		}
+		boolFlag, isBool := f.Value.(interface{ IsBoolFlag() bool })
+		for _, value := range values {
+			if b, ok := parseBool(value); ok && isBool && boolFlag.IsBoolFlag() {
+				value = strconv.FormatBool(b)
+			}
+			if setErr := f.Value.Set(value); setErr != nil {
+				err = errors.New(at("bindflags", conf.where(section, f.Name)) + " key \"" + f.Name + "\" in section \"" + section + "\" is not a valid value for flag -" + f.Name + ": " + setErr.Error())
+				return
+			}
+		}
+	})
+	return err
+}
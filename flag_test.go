@@ -0,0 +1,66 @@
+package conf
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(s string) error { *l = append(*l, s); return nil }
+
+func TestBindFlags(t *testing.T) {
+	conf, err := ParseString("[app]\nverbose=yes\nquiet=off\nport=8080\nname=file\ntag[]=a\ntag[]=b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	verbose := fs.Bool("verbose", false, "")
+	quiet := fs.Bool("quiet", true, "")
+	port := fs.Int("port", 80, "")
+	name := fs.String("name", "default", "")
+	other := fs.String("other", "default", "")
+	var tags listFlag
+	fs.Var(&tags, "tag", "")
+	if err := fs.Parse([]string{"-name", "cli"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.BindFlags(fs, "app"); err != nil {
+		t.Fatal(err)
+	}
+	if !*verbose || *quiet || *port != 8080 {
+		t.Errorf("verbose, quiet, port = %v, %v, %v, want true, false, 8080", *verbose, *quiet, *port)
+	}
+	if *name != "cli" || *other != "default" {
+		t.Errorf("name, other = %q, %q, want %q, %q", *name, *other, "cli", "default")
+	}
+	if got := tags.String(); got != "a,b" {
+		t.Errorf("tag = %q, want %q", got, "a,b")
+	}
+}
+
+func TestBindFlagsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"int", "[app]\nport=http\n"},
+		{"bool", "[app]\nverbose=maybe\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := ParseString(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fs := flag.NewFlagSet("app", flag.ContinueOnError)
+			fs.Int("port", 80, "")
+			fs.Bool("verbose", false, "")
+			if err := conf.BindFlags(fs, "app"); err == nil {
+				t.Error("BindFlags returned no error")
+			}
+		})
+	}
+}
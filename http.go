@@ -0,0 +1,52 @@
+package conf
+
+import (
+	"context"
+	"net/http"
+)
+
+// StatusError is returned by OpenURL if the server responds with a status other than 200 OK.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+func (err *StatusError) Error() string {
+	return "openurl: " + err.URL + " returned " + err.Status
+}
+
+// OpenURL fetches and parses a conf from url using the client of the HTTPClient option or http.DefaultClient.
+// The request is bound to ctx. A *StatusError will be returned if the server does not respond with 200 OK.
+// The include key of Includes is an error, a server cannot make the process read local files.
+func OpenURL(ctx context.Context, url string, opts ...Option) (*Conf, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	client := o.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{url, resp.StatusCode, resp.Status}
+	}
+	conf, err := parse(resp.Body, url, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := conf.noInclude("openurl"); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
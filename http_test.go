@@ -0,0 +1,73 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOpenURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app.conf":
+			w.Write([]byte("[s]\nk=v\n"))
+		case "/include.conf":
+			w.Write([]byte("include=/etc/passwd\n"))
+		case "/slow.conf":
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	conf, err := OpenURL(context.Background(), srv.URL+"/app.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conf.ReadDefault("s", "k", ""); got != "v" {
+		t.Errorf("s.k = %q, want %q", got, "v")
+	}
+
+	_, err = OpenURL(context.Background(), srv.URL+"/missing.conf")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("OpenURL(missing) error = %v, want a *StatusError with 404", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := OpenURL(ctx, srv.URL+"/slow.conf"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("OpenURL(slow) error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if _, err := OpenURL(context.Background(), srv.URL+"/include.conf", Includes(), AllowGlobalKeys()); err == nil {
+		t.Error("OpenURL with an include key returned no error")
+	}
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestOpenURLHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[s]\nk=v\n"))
+	}))
+	defer srv.Close()
+	transport := &countingTransport{}
+	if _, err := OpenURL(context.Background(), srv.URL, HTTPClient(&http.Client{Transport: transport})); err != nil {
+		t.Fatal(err)
+	}
+	if transport.requests != 1 {
+		t.Errorf("client of HTTPClient made %d requests, want 1", transport.requests)
+	}
+}
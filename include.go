@@ -0,0 +1,68 @@
+package conf
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// include merges the files named by the global include key into conf if includes are enabled.
+// Relative paths are resolved against dir, in fsys if it is not nil, and chain holds the including files
+// to detect cycles. Keys of conf override the same keys of the included files, later included files
+// override earlier ones. The keys of the included files are kept in conf.included so that only
+// the keys of conf itself are written back.
+func (conf *Conf) include(fsys fs.FS, dir string, opts []Option, chain []string) error {
+	if !conf.opts.includes {
+		return nil
+	}
+	filenames, err := conf.ReadSlice("", "include")
+	if err != nil {
+		return nil
+	}
+	included := &Conf{data: make(map[string]map[string]string), lists: make(map[string]map[string][]string), opts: conf.opts}
+	for _, filename := range filenames {
+		if fsys != nil {
+			filename = path.Join(dir, filename)
+		} else if !filepath.IsAbs(filename) {
+			filename = filepath.Join(dir, filename)
+		}
+		for _, including := range chain {
+			if including == filename || fsys == nil && samePath(including, filename) {
+				return errors.New("include: cycle " + strings.Join(append(chain, filename), " -> "))
+			}
+		}
+		var other *Conf
+		if fsys != nil {
+			other, err = openFS(fsys, filename, opts, chain)
+		} else {
+			other, err = open(filename, opts, chain)
+		}
+		if err != nil {
+			return err
+		}
+		included.merge(other)
+	}
+	base := &Conf{data: make(map[string]map[string]string), lists: make(map[string]map[string][]string), opts: conf.opts}
+	base.merge(included)
+	base.merge(conf)
+	conf.data, conf.lists, conf.parents, conf.included = base.data, base.lists, base.parents, included
+	return nil
+}
+
+// noInclude returns an error if the conf names files to include but has no location to resolve them against,
+// which is the case for confs parsed from a reader or fetched from a URL.
+func (conf *Conf) noInclude(op string) error {
+	if _, exists := conf.data[""]["include"]; conf.opts.includes && exists {
+		return errors.New(op + ": include is not supported for confs without a file")
+	}
+	return nil
+}
+
+// samePath reports whether a and b refer to the same file path.
+func samePath(a, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && a == b
+}
@@ -0,0 +1,58 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOpenFSIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dir/main.conf":  {Data: []byte("include = other.conf\n[a]\nk=main\n")},
+		"dir/other.conf": {Data: []byte("[a]\nk=other\nj=other\n")},
+	}
+	conf, err := OpenFS(fsys, "dir/main.conf", Includes(), AllowGlobalKeys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conf.ReadDefault("a", "k", ""); got != "main" {
+		t.Errorf("a.k = %q, want %q", got, "main")
+	}
+	if got := conf.ReadDefault("a", "j", ""); got != "other" {
+		t.Errorf("a.j = %q, want %q", got, "other")
+	}
+}
+
+func TestParseRejectsInclude(t *testing.T) {
+	if _, err := ParseString("include = other.conf\n", Includes(), AllowGlobalKeys()); err == nil {
+		t.Error("Parse with an include key returned no error")
+	}
+}
+
+func TestSaveIncludes(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.conf")
+	if err := os.WriteFile(filepath.Join(dir, "inc.conf"), []byte("[a]\ny=2\nw=1\n[b]\nk=v\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(main, []byte("include=inc.conf\n[a]\nx=3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := Open(main, Includes(), AllowGlobalKeys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Set("a", "z", "9")
+	conf.Set("a", "w", "4")
+	if err := conf.Save(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(main)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "include=inc.conf\n[a]\nx=3\nw=4\nz=9\n"; string(b) != want {
+		t.Errorf("saved %q, want %q", b, want)
+	}
+}
@@ -0,0 +1,28 @@
+package conf
+
+// inherited is like resolve but follows the parents of a section if the key does not exist in it,
+// then falls back to the default section if the section exists.
+// The returned section is the one holding the key.
+func (conf *Conf) inherited(section, key string) (string, string, bool) {
+	section, found := findSection(conf.data, section, conf.opts.caseInsensitive)
+	section, key, exists := conf.resolve(section, key)
+	for i := 0; !exists && i < len(conf.parents); i++ { //bounded to stop at cyclic parents
+		parent, ok := conf.parents[section]
+		if !ok {
+			break
+		}
+		section, key, exists = conf.resolve(parent, key)
+	}
+	if !exists && found && conf.opts.defaultSection != "" {
+		return conf.resolve(conf.opts.defaultSection, key)
+	}
+	return section, key, exists
+}
+
+// header renders the header line of a section without line ending, including its parent if any.
+func (conf *Conf) header(section string) string {
+	if parent, ok := conf.parents[section]; ok {
+		return "[" + section + " : " + parent + "]"
+	}
+	return "[" + section + "]"
+}
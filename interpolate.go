@@ -0,0 +1,140 @@
+package conf
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// expand applies the read time expansions enabled by options to a value of a given section and key.
+func (conf *Conf) expand(section, key, value string) (string, error) {
+	if conf.opts.interpolate {
+		var err error
+		value, err = conf.interpolate(value, []string{section + "." + key})
+		if err != nil {
+			return "", err
+		}
+	}
+	if conf.opts.expandEnv {
+		value = expandEnv(value)
+	}
+	if conf.opts.windows {
+		value = expandWindowsEnv(value)
+	}
+	return value, nil
+}
+
+// interpolate replaces ${section.key} references in value with the referenced values.
+// References without a dot are left untouched. Global keys are referenced as ${.key}.
+// refs holds the chain of references being expanded to detect cycles.
+func (conf *Conf) interpolate(value string, refs []string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+		end := strings.Index(value[start:], "}")
+		if end < 0 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+		ref := value[start+2 : start+end]
+		i := strings.LastIndex(ref, ".")
+		if i < 0 {
+			b.WriteString(value[:start+end+1])
+			value = value[start+end+1:]
+			continue
+		}
+		for _, seen := range refs {
+			if seen == ref {
+				return "", errors.New(at("read", conf.filename) + " reference cycle: " + strings.Join(append(refs, ref), " -> "))
+			}
+		}
+		section, key := ref[:i], ref[i+1:]
+		raw, exists := conf.lookup(section, key)
+		if !exists {
+			return "", errors.New(at("read", conf.filename) + " key \"" + key + "\" referenced by " + refs[len(refs)-1] + " does not exist in section \"" + section + "\"")
+		}
+		expanded, err := conf.interpolate(raw, append(refs, ref))
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value[:start] + expanded)
+		value = value[start+end+1:]
+	}
+}
+
+// expandEnv replaces $NAME and ${NAME} in value with the environment variable NAME.
+// Unset variables are replaced with an empty string, a $ not followed by a valid name is left untouched.
+func expandEnv(value string) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(value, '$')
+		if i < 0 {
+			b.WriteString(value)
+			return b.String()
+		}
+		b.WriteString(value[:i])
+		value = value[i:]
+		name, n := envName(value)
+		if n == 0 {
+			b.WriteByte('$')
+			value = value[1:]
+			continue
+		}
+		b.WriteString(os.Getenv(name))
+		value = value[n:]
+	}
+}
+
+// expandWindowsEnv replaces %NAME% in value with the environment variable NAME.
+// Percent signs not enclosing the name of a set variable are left untouched like on Windows.
+func expandWindowsEnv(value string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(value, '%')
+		end := strings.IndexByte(value[start+1:], '%')
+		if start < 0 || end < 0 {
+			b.WriteString(value)
+			return b.String()
+		}
+		if env, ok := os.LookupEnv(value[start+1 : start+1+end]); ok && end > 0 {
+			b.WriteString(value[:start] + env)
+			value = value[start+end+2:]
+			continue
+		}
+		b.WriteString(value[:start+1])
+		value = value[start+1:]
+	}
+}
+
+// envName returns the variable name of a $NAME or ${NAME} at the start of s and its length including $ and braces.
+func envName(s string) (string, int) {
+	if strings.HasPrefix(s, "${") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 || !isEnvName(s[2:end]) {
+			return "", 0
+		}
+		return s[2:end], end + 1
+	}
+	n := 1
+	for n < len(s) && isEnvName(s[1:n+1]) {
+		n++
+	}
+	if n == 1 {
+		return "", 0
+	}
+	return s[1:n], n
+}
+
+// isEnvName reports whether name is a valid environment variable name.
+func isEnvName(name string) bool {
+	for i, chr := range name {
+		if chr != '_' && (chr < 'A' || chr > 'Z') && (chr < 'a' || chr > 'z') && (i == 0 || chr < '0' || chr > '9') {
+			return false
+		}
+	}
+	return name != ""
+}
@@ -0,0 +1,54 @@
+package conf
+
+import "testing"
+
+func TestInterpolate(t *testing.T) {
+	conf, err := ParseString("root=/srv\n[paths]\nbase=${.root}/app\nlogs=${paths.base}/logs\nliteral=${HOME} and ${\n[bad]\nmissing=${paths.none}\ncycle=${bad.loop}\nloop=${bad.cycle}\n", Interpolate(), AllowGlobalKeys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"base", "/srv/app"},
+		{"logs", "/srv/app/logs"},
+		{"literal", "${HOME} and ${"},
+	}
+	for _, tt := range tests {
+		if got, err := conf.Read("paths", tt.key); err != nil || got != tt.want {
+			t.Errorf("Read(paths, %s) = %q, %v, want %q", tt.key, got, err, tt.want)
+		}
+	}
+	for _, key := range []string{"missing", "cycle"} {
+		if _, err := conf.Read("bad", key); err == nil {
+			t.Errorf("Read(bad, %s) returned no error", key)
+		}
+	}
+	if got := conf.Map()["paths"]["logs"]; got != "${paths.base}/logs" {
+		t.Errorf("Map()[paths][logs] = %q, want the value as written", got)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("CONF_TEST_DIR", "/tmp/x")
+	conf, err := ParseString("[s]\na=$CONF_TEST_DIR/a\nb=${CONF_TEST_DIR}b\nunset=[$CONF_TEST_UNSET]\ndollar=costs 5$ or $1\nref=${s.a}\n", ExpandEnv(), Interpolate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"a", "/tmp/x/a"},
+		{"b", "/tmp/xb"},
+		{"unset", "[]"},
+		{"dollar", "costs 5$ or $1"},
+		{"ref", "/tmp/x/a"},
+	}
+	for _, tt := range tests {
+		if got, err := conf.Read("s", tt.key); err != nil || got != tt.want {
+			t.Errorf("Read(s, %s) = %q, %v, want %q", tt.key, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// ToJSON returns the sections and keys as a JSON object of sections holding objects of keys,
+// e.g. {"server": {"port": "8080"}}. Global keys are in the section "".
+// Values are strings as written in the file, list keys are arrays of strings.
+func (conf *Conf) ToJSON() ([]byte, error) {
+	m := make(map[string]map[string]any, len(conf.data))
+	for section, keys := range conf.data {
+		m[section] = make(map[string]any, len(keys))
+		for key, value := range keys {
+			if list, isList := conf.lists[section][key]; isList {
+				m[section][key] = list
+			} else {
+				m[section][key] = value
+			}
+		}
+	}
+	return json.Marshal(m)
+}
+
+// MarshalJSON is like ToJSON, it implements json.Marshaler.
+func (conf *Conf) MarshalJSON() ([]byte, error) {
+	return conf.ToJSON()
+}
+
+// FromJSON builds a conf from a JSON object of sections holding objects of keys as written by ToJSON.
+// Numbers, booleans and null are read as their text and an empty value, arrays become list keys and an empty array an empty value.
+func FromJSON(b []byte) (*Conf, error) {
+	var m map[string]map[string]any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	conf := &Conf{data: make(map[string]map[string]string), lists: make(map[string]map[string][]string), opts: options{delimiters: "=", comments: defaultComments}}
+	for section, keys := range m {
+		conf.data[section] = make(map[string]string, len(keys))
+		for key, v := range keys {
+			elems, isList := v.([]any)
+			if !isList {
+				value, ok := jsonValue(v)
+				if !ok {
+					return nil, errors.New("fromjson: key \"" + key + "\" in section \"" + section + "\" is not a string, number, boolean or array")
+				}
+				conf.data[section][key] = value
+				continue
+			}
+			list := make([]string, len(elems))
+			for i, elem := range elems {
+				value, ok := jsonValue(elem)
+				if !ok {
+					return nil, errors.New("fromjson: key \"" + key + "\" in section \"" + section + "\" has an element that is not a string, number or boolean")
+				}
+				list[i] = value
+			}
+			conf.data[section][key] = ""
+			if len(list) == 0 {
+				continue
+			}
+			if conf.lists[section] == nil {
+				conf.lists[section] = make(map[string][]string)
+			}
+			conf.lists[section][key] = list
+			conf.data[section][key] = list[len(list)-1]
+		}
+	}
+	return conf, nil
+}
+
+// jsonValue returns the text of a decoded JSON scalar and false for objects and arrays.
+func jsonValue(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case nil:
+		return "", true
+	}
+	return "", false
+}
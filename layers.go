@@ -0,0 +1,138 @@
+package conf
+
+import "os"
+
+// Layers resolves keys through a stack of sources in order of priority:
+// overrides set with Set, environment variables enabled with Env, files in reverse order of adding and defaults.
+// The zero value is an empty stack ready to use.
+type Layers struct {
+	defaults  *Conf
+	files     []*Conf
+	env       bool
+	prefix    string
+	overrides *Conf
+}
+
+// Defaults sets the lowest layer to the sections and keys of m, e.g. {"server": {"port": "8080"}}.
+func (l *Layers) Defaults(m map[string]map[string]string) {
+	l.defaults = &Conf{}
+	for section, keys := range m {
+		for key, value := range keys {
+			l.defaults.Set(section, key, value)
+		}
+	}
+}
+
+// Add adds a conf as a layer above the defaults and the confs added before.
+func (l *Layers) Add(conf *Conf) {
+	l.files = append(l.files, conf)
+}
+
+// AddFile opens a conf file and adds it like Add.
+func (l *Layers) AddFile(filename string, opts ...Option) error {
+	conf, err := Open(filename, opts...)
+	if err != nil {
+		return err
+	}
+	l.Add(conf)
+	return nil
+}
+
+// Env enables the layer of environment variables above all confs.
+// The variable of a key is named by its prefix, section and key in upper case joined with underscores,
+// other characters than letters and digits are replaced with underscores: APP_SERVER_PORT for key port in section server.
+// Global keys leave out the section and an empty prefix is left out with its underscore.
+func (l *Layers) Env(prefix string) {
+	l.env = true
+	l.prefix = prefix
+}
+
+// Set sets an override in the highest layer.
+func (l *Layers) Set(section, key, value string) {
+	if l.overrides == nil {
+		l.overrides = &Conf{}
+	}
+	l.overrides.Set(section, key, value)
+}
+
+// Read returns the value to a given section and key from the layer with the highest priority having the key.
+// An error wrapping ErrKeyNotFound will be returned if no layer has the key, also wrapping ErrSectionNotFound
+// if no layer has the section.
+func (l *Layers) Read(section, key string) (string, error) {
+	if l.overrides != nil && l.overrides.HasKey(section, key) {
+		return l.overrides.Read(section, key)
+	}
+	if l.env {
+		if value, ok := os.LookupEnv(envVar(l.prefix, section, key)); ok {
+			return value, nil
+		}
+	}
+	for i := len(l.files) - 1; i >= 0; i-- {
+		if l.files[i].HasKey(section, key) {
+			return l.files[i].Read(section, key)
+		}
+	}
+	if l.defaults != nil && l.defaults.HasKey(section, key) {
+		return l.defaults.Read(section, key)
+	}
+	for _, conf := range l.confs() {
+		if conf.HasSection(section) {
+			return "", &notFoundError{"read: key \"" + key + "\" does not exist in section \"" + section + "\" of any layer", []error{ErrKeyNotFound}}
+		}
+	}
+	return "", &notFoundError{"read: section \"" + section + "\" of key \"" + key + "\" does not exist in any layer", []error{ErrSectionNotFound, ErrKeyNotFound}}
+}
+
+// ReadDefault returns the value to a given section and key like Read or def if no layer has the key.
+func (l *Layers) ReadDefault(section, key, def string) string {
+	value, err := l.Read(section, key)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// Conf returns the layers merged into a single conf to use its typed getters and Unmarshal.
+// Environment variables only override keys of the other layers, values are merged as written.
+func (l *Layers) Conf() *Conf {
+	conf := &Conf{data: make(map[string]map[string]string), lists: make(map[string]map[string][]string), opts: options{delimiters: "=", comments: defaultComments}}
+	confs := l.confs()
+	if l.overrides != nil {
+		confs = confs[:len(confs)-1]
+	}
+	for _, other := range confs {
+		conf.merge(other)
+	}
+	if l.env {
+		keys := make(map[[2]string]bool)
+		for _, other := range l.confs() {
+			for section, values := range other.data {
+				for key := range values {
+					keys[[2]string{section, key}] = true
+				}
+			}
+		}
+		for k := range keys {
+			if value, ok := os.LookupEnv(envVar(l.prefix, k[0], k[1])); ok {
+				conf.Set(k[0], k[1], value)
+			}
+		}
+	}
+	if l.overrides != nil {
+		conf.merge(l.overrides)
+	}
+	return conf
+}
+
+// confs returns the defaults, files and overrides in order of increasing priority.
+func (l *Layers) confs() []*Conf {
+	var confs []*Conf
+	if l.defaults != nil {
+		confs = append(confs, l.defaults)
+	}
+	confs = append(confs, l.files...)
+	if l.overrides != nil {
+		confs = append(confs, l.overrides)
+	}
+	return confs
+}
@@ -0,0 +1,58 @@
+package conf
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLayers(t *testing.T) {
+	t.Setenv("APP_SERVER_HOST", "env")
+	t.Setenv("APP_SERVER_PORT", "9000")
+	var l Layers
+	l.Defaults(map[string]map[string]string{"server": {"host": "default", "port": "80", "timeout": "1s", "mode": "default"}})
+	l.Add(parseTest(t, "[server]\nport=8080\nmode=first\n"))
+	l.Add(parseTest(t, "[server]\nmode=second\n"))
+	l.Env("app")
+	l.Set("server", "port", "override")
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"timeout", "1s"},
+		{"mode", "second"},
+		{"host", "env"},
+		{"port", "override"},
+	}
+	for _, tt := range tests {
+		if got, err := l.Read("server", tt.key); err != nil || got != tt.want {
+			t.Errorf("Read(server, %s) = %q, %v, want %q", tt.key, got, err, tt.want)
+		}
+	}
+	if got := l.ReadDefault("server", "missing", "def"); got != "def" {
+		t.Errorf("ReadDefault(server, missing) = %q, want %q", got, "def")
+	}
+	if _, err := l.Read("server", "missing"); !errors.Is(err, ErrKeyNotFound) || errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("Read(server, missing) error = %v, want ErrKeyNotFound only", err)
+	}
+	if _, err := l.Read("other", "key"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("Read(other, key) error = %v, want ErrSectionNotFound", err)
+	}
+
+	conf := l.Conf()
+	for _, tt := range tests {
+		if got := conf.ReadDefault("server", tt.key, ""); got != tt.want {
+			t.Errorf("Conf().Read(server, %s) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLayersZeroValue(t *testing.T) {
+	var l Layers
+	if _, err := l.Read("s", "k"); !errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("Read of empty layers error = %v, want ErrSectionNotFound", err)
+	}
+	if got := l.Conf().Sections(); len(got) != 0 {
+		t.Errorf("Conf().Sections() = %q, want none", got)
+	}
+}
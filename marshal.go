@@ -0,0 +1,221 @@
+package conf
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal returns the struct v or the struct it points to in conf file format, the inverse of Unmarshal.
+// Fields are matched to sections and keys like Unmarshal and written in struct order, global keys first.
+// Slices are written as list keys, empty slices as empty values; nil pointers are left out.
+// Types implementing encoding.TextMarshaler are written as their text, structs as sections
+// and slices of structs as repeated sections with a subsection name.
+func Marshal(v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errors.New("marshal: argument must be a struct or a pointer to a struct")
+	}
+	enc := &encoder{conf: &Conf{data: make(map[string]map[string]string), lists: make(map[string]map[string][]string)}, keys: make(map[string][]string)}
+	if err := enc.marshal(rv, "", true); err != nil {
+		return nil, err
+	}
+	return []byte(enc.String()), nil
+}
+
+// encoder collects the keys of a marshaled struct in order.
+type encoder struct {
+	conf     *Conf
+	sections []string
+	keys     map[string][]string
+}
+
+// marshal adds the fields of the struct rv to section.
+// If dotted is set, tags may name another section with a "section." prefix.
+func (enc *encoder) marshal(rv reflect.Value, section string, dotted bool) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.Anonymous && field.Tag.Get("conf") == "" && enc.conf.isSection(field.Type) {
+			v := rv.Field(i)
+			if v.Kind() == reflect.Pointer {
+				if v.IsNil() {
+					continue
+				}
+				v = v.Elem()
+			}
+			if err := enc.marshal(v, section, dotted); err != nil {
+				return err
+			}
+			continue
+		}
+		tag := parseTag(field)
+		name := tag.name
+		if !field.IsExported() || name == "-" || tag.isName {
+			continue
+		}
+		if field.Type.Kind() == reflect.Slice && enc.conf.isSection(field.Type.Elem()) {
+			if err := enc.marshalSections(rv.Field(i), childSection(section, name)); err != nil {
+				return err
+			}
+			continue
+		}
+		if v := rv.Field(i); enc.conf.isSection(field.Type) {
+			if v.Kind() == reflect.Pointer {
+				if v.IsNil() {
+					continue
+				}
+				v = v.Elem()
+			}
+			if err := enc.marshal(v, childSection(section, name), false); err != nil {
+				return err
+			}
+			continue
+		}
+		section, key := section, name
+		if i := strings.LastIndex(name, "."); dotted && i >= 0 {
+			section, key = name[:i], name[i+1:]
+		}
+		if err := enc.encodeKey(rv.Field(i), section, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalSections adds the structs or pointers to structs in the slice v as sections named like [name "subsection"],
+// with the subsection taken from the field tagged with the name option.
+func (enc *encoder) marshalSections(v reflect.Value, name string) error {
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Pointer {
+			if elem.IsNil() {
+				continue
+			}
+			elem = elem.Elem()
+		}
+		subsection := ""
+		for j := 0; j < elem.NumField(); j++ {
+			if parseTag(elem.Type().Field(j)).isName && elem.Field(j).Kind() == reflect.String {
+				subsection = elem.Field(j).String()
+			}
+		}
+		section := name + " \"" + subsectionEscaper.Replace(subsection) + "\""
+		if _, exists := enc.conf.data[section]; !exists {
+			enc.conf.data[section] = make(map[string]string)
+			enc.sections = append(enc.sections, section)
+		}
+		if err := enc.marshal(elem, section, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeKey adds the key for the field value v.
+func (enc *encoder) encodeKey(v reflect.Value, section, key string) error {
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+	var value string
+	var list []string
+	if v.Kind() == reflect.Slice && !v.Type().Implements(textMarshalerType) {
+		list = make([]string, v.Len())
+		for i := range list {
+			elem, err := encode(v.Index(i))
+			if err != nil {
+				return encodeError(err, section, key, v.Type().Elem())
+			}
+			list[i] = elem
+		}
+		if len(list) > 0 {
+			value = list[len(list)-1]
+		}
+	} else {
+		var err error
+		if value, err = encode(v); err != nil {
+			return encodeError(err, section, key, v.Type())
+		}
+	}
+
+	if _, exists := enc.conf.data[section]; !exists {
+		enc.conf.data[section] = make(map[string]string)
+		enc.sections = append(enc.sections, section)
+	}
+	if _, exists := enc.conf.data[section][key]; !exists {
+		enc.keys[section] = append(enc.keys[section], key)
+	}
+	enc.conf.data[section][key] = value
+	delete(enc.conf.lists[section], key)
+	if len(list) > 0 {
+		if enc.conf.lists[section] == nil {
+			enc.conf.lists[section] = make(map[string][]string)
+		}
+		enc.conf.lists[section][key] = list
+	}
+	return nil
+}
+
+// String renders the collected keys, global keys first and then the sections in order.
+func (enc *encoder) String() string {
+	var b strings.Builder
+	for _, key := range enc.keys[""] {
+		b.WriteString(enc.conf.formatKeys("", key, nil))
+	}
+	for _, section := range enc.sections {
+		if section == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(enc.conf.header(section) + "\n")
+		for _, key := range enc.keys[section] {
+			b.WriteString(enc.conf.formatKeys(section, key, nil))
+		}
+	}
+	return b.String()
+}
+
+// encodeError returns the error of Marshal for a value of type t that could not be converted by encode.
+func encodeError(err error, section, key string, t reflect.Type) error {
+	return errors.New("marshal: " + err.Error() + " " + t.String() + " for key \"" + key + "\" in section \"" + section + "\"")
+}
+
+// encode converts v to a value in the format read by decode.
+func encode(v reflect.Value) (string, error) {
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String(), nil
+	case timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339), nil
+	}
+	if v.Kind() != reflect.Pointer && v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Pointer:
+		if v.IsNil() {
+			return "", nil
+		}
+		return encode(v.Elem())
+	}
+	return "", errUnsupported
+}
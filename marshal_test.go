@@ -0,0 +1,78 @@
+package conf
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarshalTextMarshalerSlice(t *testing.T) {
+	v := struct {
+		IP net.IP `conf:"s.ip"`
+	}{net.IPv4(10, 0, 0, 1)}
+	b, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[s]\nip=10.0.0.1\n"; string(b) != want {
+		t.Errorf("Marshal = %q, want %q", b, want)
+	}
+}
+
+type marshalUpstream struct {
+	Name string `conf:",name"`
+	URL  string `conf:"url"`
+}
+
+type marshalConfig struct {
+	Debug  bool `conf:"debug"`
+	Server struct {
+		Host    string        `conf:"host"`
+		Port    int           `conf:"port"`
+		Timeout time.Duration `conf:"timeout"`
+		Tags    []string      `conf:"tags"`
+		Empty   []string      `conf:"empty"`
+		Nil     *string       `conf:"nil"`
+		Quoted  string        `conf:"quoted"`
+	}
+	Upstreams []marshalUpstream `conf:"upstream"`
+}
+
+func TestMarshal(t *testing.T) {
+	var v marshalConfig
+	v.Debug = true
+	v.Server.Host = "h"
+	v.Server.Port = 80
+	v.Server.Timeout = time.Minute
+	v.Server.Tags = []string{"a", "b"}
+	v.Server.Empty = []string{}
+	v.Server.Quoted = " x ; y"
+	v.Upstreams = []marshalUpstream{{"a", "A"}, {"b", "B"}}
+	b, err := Marshal(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "debug=true\n\n[server]\nhost=h\nport=80\ntimeout=1m0s\ntags[]=a\ntags[]=b\nempty=\nquoted=\" x ; y\"\n\n[upstream \"a\"]\nurl=A\n\n[upstream \"b\"]\nurl=B\n"
+	if string(b) != want {
+		t.Errorf("Marshal = %q, want %q", b, want)
+	}
+
+	var got marshalConfig
+	if err := parseTest(t, string(b), AllowGlobalKeys()).Unmarshal(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, v) {
+		t.Errorf("Unmarshal of Marshal = %+v, want %+v", got, v)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	for _, v := range []any{nil, 1, (*marshalConfig)(nil), struct {
+		C complex64 `conf:"s.c"`
+	}{}} {
+		if _, err := Marshal(v); err == nil {
+			t.Errorf("Marshal(%#v) returned no error", v)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package conf
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// Option configures how a conf is parsed and read.
+type Option func(*options)
+
+type options struct {
+	duplicateKeys   DuplicateKeyPolicy
+	interpolate     bool
+	expandEnv       bool
+	includes        bool
+	caseInsensitive bool
+	delimiters      string
+	comments        []string
+	mergeSections   bool
+	valuelessKeys   bool
+	inheritance     bool
+	defaultSection  string
+	strict          bool
+	globalKeys      bool
+	allErrors       bool
+	lenient         bool
+	warn            func(*ParseError)
+	envName         func(section, key string) string
+	systemd         bool
+	windows         bool
+	client          *http.Client
+	hooks           map[reflect.Type]func(string) (reflect.Value, error)
+}
+
+// DuplicateKeyPolicy decides how keys appearing multiple times in a section are parsed.
+type DuplicateKeyPolicy int
+
+const (
+	DuplicateError   DuplicateKeyPolicy = iota // duplicate keys are a parse error
+	DuplicateFirst                             // the first value is kept
+	DuplicateLast                              // the last value is kept
+	DuplicateCollect                           // all values are kept as a list
+)
+
+// DuplicateKeys sets the policy for keys appearing multiple times in a section, DuplicateError by default.
+// Overridden lines are kept when writing as long as the key exists.
+func DuplicateKeys(policy DuplicateKeyPolicy) Option {
+	return func(opts *options) {
+		opts.duplicateKeys = policy
+	}
+}
+
+// CollectDuplicates makes keys appearing multiple times in a section a list of all their values
+// instead of a duplicate key error. All values are returned by ReadAll, Read returns the last one.
+// It is a shorthand for DuplicateKeys(DuplicateCollect).
+func CollectDuplicates() Option {
+	return DuplicateKeys(DuplicateCollect)
+}
+
+// Interpolate enables ${section.key} references in values which are replaced with the referenced value at read time,
+// e.g. logdir=${paths.base}/logs. Global keys are referenced as ${.key}, references without a dot are left untouched.
+// Reading a value with a missing reference or a reference cycle returns an error.
+func Interpolate() Option {
+	return func(opts *options) {
+		opts.interpolate = true
+	}
+}
+
+// ExpandEnv enables expansion of $NAME and ${NAME} in values with environment variables at read time.
+// Unset variables expand to an empty string. Combined with Interpolate, references are expanded first.
+func ExpandEnv() Option {
+	return func(opts *options) {
+		opts.expandEnv = true
+	}
+}
+
+// Includes enables the global include key which names files to merge into the conf at parse time,
+// e.g. include=conf.d/local.conf before the first section. Use include[] to include several files.
+// Relative paths are resolved against the directory of the including file, in the fs.FS for OpenFS.
+// Confs from Parse and OpenURL have no location, an include key is an error for them.
+// Keys of the including file override the same keys of included files.
+// Saving a conf with includes writes only the keys of its own file and keys changed after parsing.
+func Includes() Option {
+	return func(opts *options) {
+		opts.includes = true
+	}
+}
+
+// CaseInsensitive makes section and key names match regardless of case when reading and editing,
+// so Read("Server", "Port") and Read("server", "port") are equivalent.
+// Sections and keys differing only in case are duplicates. The original case is kept for writing.
+// Quoted subsections like [remote "Origin"] stay case-sensitive as in git.
+func CaseInsensitive() Option {
+	return func(opts *options) {
+		opts.caseInsensitive = true
+	}
+}
+
+// Delimiters sets the characters separating keys from values, "=" by default.
+// For example Delimiters("=:") additionally accepts key: value lines. Values are always written with "=".
+func Delimiters(delimiters string) Option {
+	return func(opts *options) {
+		if delimiters != "" {
+			opts.delimiters = delimiters
+		}
+	}
+}
+
+// MergeSections makes a section appearing multiple times add its keys to the first occurrence
+// instead of a duplicate section error. Keys repeated across the occurrences are still duplicates.
+func MergeSections() Option {
+	return func(opts *options) {
+		opts.mergeSections = true
+	}
+}
+
+// ValuelessKeys allows keys without a delimiter and value, e.g. a line "verbose" as used by MySQL option files.
+// Such keys are read as an empty value, use HasKey to test for them.
+func ValuelessKeys() Option {
+	return func(opts *options) {
+		opts.valuelessKeys = true
+	}
+}
+
+// Inheritance enables section headers of the form [child : parent].
+// Keys missing in the child section are read from the parent section and its ancestors.
+func Inheritance() Option {
+	return func(opts *options) {
+		opts.inheritance = true
+	}
+}
+
+// DefaultSection makes keys missing in an existing section fall back to the given section,
+// like the [DEFAULT] section of Python's configparser. Missing sections are not affected.
+func DefaultSection(name string) Option {
+	return func(opts *options) {
+		opts.defaultSection = name
+	}
+}
+
+// defaultComments are the comment prefixes used unless CommentPrefixes is given.
+var defaultComments = []string{"#", ";"}
+
+// CommentPrefixes sets the prefixes starting a comment, "#" and ";" by default.
+// Prefixes may be longer than one character, e.g. "//". An empty list is ignored.
+// Inline comments must be preceded by whitespace.
+func CommentPrefixes(prefixes ...string) Option {
+	return func(opts *options) {
+		if len(prefixes) > 0 {
+			opts.comments = prefixes
+		}
+	}
+}
+
+// Strict rejects files that are only readable by this package's relaxed defaults:
+// keys before the first section and empty section names are errors.
+func Strict() Option {
+	return func(opts *options) {
+		opts.strict = true
+	}
+}
+
+// AllowGlobalKeys allows keys before the first section in Strict mode. They are always allowed otherwise.
+func AllowGlobalKeys() Option {
+	return func(opts *options) {
+		opts.globalKeys = true
+	}
+}
+
+// AllErrors keeps parsing after an error and returns all parse errors joined by errors.Join.
+// No conf is returned if there was any error.
+func AllErrors() Option {
+	return func(opts *options) {
+		opts.allErrors = true
+	}
+}
+
+// Warnings calls fn for recoverable issues found while parsing: trailing whitespace, control characters,
+// invalid UTF-8 and keys shadowed by the DuplicateFirst or DuplicateLast policies.
+// Warnings never fail the parse, fn is called once the line of the warning has been read.
+func Warnings(fn func(warning *ParseError)) Option {
+	return func(opts *options) {
+		opts.warn = fn
+	}
+}
+
+// Lenient skips malformed lines instead of failing the parse and reports them to the Warnings callback.
+// Keys following a malformed section header are skipped up to the next valid header, each reported as well.
+// Skipped lines are kept as they are when writing the conf.
+func Lenient() Option {
+	return func(opts *options) {
+		opts.lenient = true
+	}
+}
+
+// EnvOverrides makes environment variables override keys when reading, e.g. APP_SERVER_PORT=9090 overrides
+// key port in section server for the prefix "APP". Variables are named like for Layers.Env and also provide keys
+// missing in the file. Only reading is affected, Map, String and Save use the values of the file.
+func EnvOverrides(prefix string) Option {
+	return EnvOverridesFunc(func(section, key string) string {
+		return envVar(prefix, section, key)
+	})
+}
+
+// EnvOverridesFunc is like EnvOverrides with fn returning the name of the environment variable of a key.
+func EnvOverridesFunc(fn func(section, key string) string) Option {
+	return func(opts *options) {
+		opts.envName = fn
+	}
+}
+
+// Systemd parses the dialect of systemd unit files: repeated keys are collected into lists and an empty
+// assignment like Key= resets the key. # and ; start comments only at the beginning of a line, quotes are part
+// of the value and a backslash at the end of a line continues the value after a space.
+func Systemd() Option {
+	return func(opts *options) {
+		opts.systemd = true
+		opts.duplicateKeys = DuplicateCollect
+		opts.comments = []string{"#", ";"}
+	}
+}
+
+// WindowsINI parses classic Windows INI files unchanged: names are case-insensitive, the first of repeated keys
+// is kept and repeated sections are merged. ; and # start comments only at the beginning of a line,
+// surrounding quotes of a value are stripped without escapes, a backslash at the end of a line is part of
+// the value and %NAME% is replaced with the environment variable NAME when reading. Lines are written with CRLF.
+func WindowsINI() Option {
+	return func(opts *options) {
+		opts.windows = true
+		opts.caseInsensitive = true
+		opts.duplicateKeys = DuplicateFirst
+		opts.mergeSections = true
+		opts.comments = []string{";", "#"}
+	}
+}
+
+// DecodeHook registers fn to convert values to T when unmarshaling into fields of type T.
+// Hooks take precedence over the built-in conversions and encoding.TextUnmarshaler,
+// e.g. for third-party types or formats like "1h@03:00".
+func DecodeHook[T any](fn func(value string) (T, error)) Option {
+	return func(opts *options) {
+		hooks := make(map[reflect.Type]func(string) (reflect.Value, error), len(opts.hooks)+1)
+		for t, hook := range opts.hooks {
+			hooks[t] = hook
+		}
+		hooks[reflect.TypeOf((*T)(nil)).Elem()] = func(value string) (reflect.Value, error) {
+			v, err := fn(value)
+			return reflect.ValueOf(&v).Elem(), err
+		}
+		opts.hooks = hooks
+	}
+}
+
+// HTTPClient sets the client OpenURL fetches the conf with, http.DefaultClient is used otherwise.
+func HTTPClient(client *http.Client) Option {
+	return func(opts *options) {
+		opts.client = client
+	}
+}
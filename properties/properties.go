@@ -0,0 +1,201 @@
+// Package properties converts confs to Java properties files and back.
+// Keys are mapped into sections by their first path component:
+//
+//	name=global key without a dot
+//	server.port=key port in section server
+//	server.tls.cert=key tls.cert in section server
+//
+// Documents are read and written as UTF-8, escapes like \u00e9 are supported.
+package properties
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/hirsch/conf"
+)
+
+// ToProperties returns the sections and keys of c as a properties file in file order.
+// Keys are written as section.key, list keys with their elements separated by commas.
+// Dotted section names are not preserved by FromProperties, [server.tls] cert is read back as key tls.cert in section server.
+func ToProperties(c *conf.Conf) ([]byte, error) {
+	b, err := c.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	var out strings.Builder
+	for _, section := range append([]string{""}, c.Sections()...) {
+		keys, _ := c.Keys(section)
+		for _, key := range keys {
+			value, isString := m[section][key].(string)
+			if !isString {
+				var elems []string
+				for _, elem := range m[section][key].([]any) {
+					elems = append(elems, elem.(string))
+				}
+				value = strings.Join(elems, ",")
+			}
+			if section != "" {
+				key = section + "." + key
+			}
+			out.WriteString(escape(key, true) + "=" + escape(value, false) + "\n")
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+// escape escapes the special characters of a key or value.
+// Spaces are escaped in keys, only a leading space in values.
+func escape(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case ' ':
+			if key || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(' ')
+		case '\\', '=', ':', '#', '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < ' ' || r == 0x7f {
+				b.WriteString(`\u` + strconv.FormatInt(int64(r)+0x10000, 16)[1:])
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// FromProperties builds a conf from a properties file.
+// The first path component of a key names its section, keys without a dot are global keys.
+// Later keys override earlier ones like in java.util.Properties.
+func FromProperties(b []byte) (*conf.Conf, error) {
+	doc := strings.ReplaceAll(strings.ReplaceAll(string(b), "\r\n", "\n"), "\r", "\n")
+	lines := strings.Split(doc, "\n")
+	m := make(map[string]map[string]string)
+	for i := 0; i < len(lines); i++ {
+		num := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		// A line ending in an odd number of backslashes continues on the next line.
+		for continued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if continued(line) {
+			line = line[:len(line)-1]
+		}
+
+		end := 0
+		for ; end < len(line) && !strings.ContainsRune("=: \t\f", rune(line[end])); end++ {
+			if line[end] == '\\' {
+				end++
+			}
+		}
+		end = min(end, len(line))
+		rest := strings.TrimLeft(line[end:], " \t\f")
+		if strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ":") {
+			rest = strings.TrimLeft(rest[1:], " \t\f")
+		}
+		key, err := unescape(line[:end], num)
+		if err != nil {
+			return nil, err
+		}
+		value, err := unescape(rest, num)
+		if err != nil {
+			return nil, err
+		}
+
+		section, name, found := strings.Cut(key, ".")
+		if !found || section == "" {
+			section, name = "", key
+		}
+		if m[section] == nil {
+			m[section] = make(map[string]string)
+		}
+		m[section][name] = value
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return conf.FromJSON(b)
+}
+
+// continued reports whether line ends in an odd number of backslashes.
+func continued(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, "\\"))
+	return n%2 == 1
+}
+
+// unescape replaces the escape sequences of a key or value, a backslash before any other character is dropped.
+func unescape(s string, num int) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			r, ok := hex(s, i+1)
+			if !ok {
+				return "", errors.New("fromproperties: line " + strconv.Itoa(num) + ": malformed \\uxxxx escape")
+			}
+			i += 4
+			// Characters outside the Basic Multilingual Plane are escaped as surrogate pairs.
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+				if low, ok := hex(s, i+3); ok {
+					if pair := utf16.DecodeRune(r, low); pair != '\uFFFD' {
+						r = pair
+						i += 6
+					}
+				}
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// hex parses the four hexadecimal digits at s[i:].
+func hex(s string, i int) (rune, bool) {
+	if i+4 > len(s) {
+		return 0, false
+	}
+	r, err := strconv.ParseUint(s[i:i+4], 16, 16)
+	return rune(r), err == nil
+}
@@ -0,0 +1,449 @@
+package conf
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"math"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadInt returns the value to a given section and key as an int.
+// An error will be returned if a key or section does not exist or the value is not a valid integer.
+func (conf *Conf) ReadInt(section, key string) (int, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, conf.valueError("readint", section, key, value, "a valid integer")
+	}
+	return i, nil
+}
+
+// ReadInt64 returns the value to a given section and key as an int64.
+// An error will be returned if a key or section does not exist or the value is not a valid integer.
+func (conf *Conf) ReadInt64(section, key string) (int64, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, conf.valueError("readint64", section, key, value, "a valid 64-bit integer")
+	}
+	return i, nil
+}
+
+// ReadBool returns the value to a given section and key as a bool.
+// Accepted values are true/false, yes/no, on/off and 1/0, regardless of case.
+// An error will be returned if a key or section does not exist or the value is not a valid boolean.
+func (conf *Conf) ReadBool(section, key string) (bool, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := parseBool(value)
+	if !ok {
+		return false, conf.valueError("readbool", section, key, value, "a valid boolean")
+	}
+	return b, nil
+}
+
+// parseBool parses the boolean values accepted by ReadBool.
+func parseBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, true
+	case "false", "no", "off", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// ReadFloat64 returns the value to a given section and key as a float64.
+// An error will be returned if a key or section does not exist or the value is not a valid number.
+func (conf *Conf) ReadFloat64(section, key string) (float64, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, conf.valueError("readfloat64", section, key, value, "a valid number")
+	}
+	return f, nil
+}
+
+// ReadDuration returns the value to a given section and key as a time.Duration.
+// Values use the time.ParseDuration syntax, e.g. 30s, 5m or 1h30m.
+// An error will be returned if a key or section does not exist or the value is not a valid duration.
+func (conf *Conf) ReadDuration(section, key string) (time.Duration, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return 0, err
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, conf.valueError("readduration", section, key, value, "a valid duration")
+	}
+	return d, nil
+}
+
+// ReadTime returns the value to a given section and key as a time.Time.
+// The value is parsed as RFC3339 first and then with each of the given layouts in order.
+// An error will be returned if a key or section does not exist or no layout matches the value.
+func (conf *Conf) ReadTime(section, key string, layouts ...string) (time.Time, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, layout := range append([]string{time.RFC3339}, layouts...) {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, conf.valueError("readtime", section, key, value, "a valid time")
+}
+
+// ReadStringSlice returns the value to a given section and key split on commas.
+// Whitespace around elements is trimmed and an empty value results in an empty slice.
+// An error will be returned if a key or section does not exist.
+func (conf *Conf) ReadStringSlice(section, key string) ([]string, error) {
+	return conf.ReadStringSliceSep(section, key, ",")
+}
+
+// ReadStringSliceSep is like ReadStringSlice but splits the value on sep.
+func (conf *Conf) ReadStringSliceSep(section, key, sep string) ([]string, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return nil, err
+	}
+	return splitList(value, sep), nil
+}
+
+// ReadIntSlice returns the comma separated value to a given section and key as a slice of ints.
+// An error naming the index of the first invalid element will be returned if an element is not a valid integer.
+func (conf *Conf) ReadIntSlice(section, key string) ([]int, error) {
+	list, err := conf.ReadStringSlice(section, key)
+	if err != nil {
+		return nil, err
+	}
+	ints := make([]int, len(list))
+	for i, elem := range list {
+		ints[i], err = strconv.Atoi(elem)
+		if err != nil {
+			return nil, conf.valueError("readintslice", section, key, elem, "a valid integer at index "+strconv.Itoa(i))
+		}
+	}
+	return ints, nil
+}
+
+// ReadBytesHex returns the hex encoded value to a given section and key decoded into bytes.
+// An error will be returned if a key or section does not exist or the value is not valid hex.
+func (conf *Conf) ReadBytesHex(section, key string) ([]byte, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, conf.valueError("readbyteshex", section, key, value, "valid hex")
+	}
+	return b, nil
+}
+
+// ReadBytesBase64 returns the standard base64 encoded value to a given section and key decoded into bytes.
+// Padding is optional.
+// An error will be returned if a key or section does not exist or the value is not valid base64.
+func (conf *Conf) ReadBytesBase64(section, key string) ([]byte, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, conf.valueError("readbytesbase64", section, key, value, "valid base64")
+	}
+	return b, nil
+}
+
+// ReadURL returns the value to a given section and key parsed as a URL.
+// An error will be returned if a key or section does not exist or the value is not a valid URL.
+func (conf *Conf) ReadURL(section, key string) (*url.URL, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, conf.valueError("readurl", section, key, value, "a valid URL")
+	}
+	return u, nil
+}
+
+// ReadAbsURL is like ReadURL but additionally requires the URL to have a scheme.
+func (conf *Conf) ReadAbsURL(section, key string) (*url.URL, error) {
+	u, err := conf.ReadURL(section, key)
+	if err != nil {
+		return nil, err
+	}
+	if !u.IsAbs() {
+		return nil, conf.valueError("readabsurl", section, key, u.String(), "an absolute URL")
+	}
+	return u, nil
+}
+
+// ReadIP returns the value to a given section and key as an IP address.
+// An error will be returned if a key or section does not exist or the value is not a valid IP address.
+func (conf *Conf) ReadIP(section, key string) (netip.Addr, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, conf.valueError("readip", section, key, value, "a valid IP address")
+	}
+	return addr, nil
+}
+
+// ReadCIDR returns the value to a given section and key as an IP prefix in CIDR notation, e.g. 10.0.0.0/8.
+// An error will be returned if a key or section does not exist or the value is not a valid prefix.
+func (conf *Conf) ReadCIDR(section, key string) (netip.Prefix, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return netip.Prefix{}, conf.valueError("readcidr", section, key, value, "a valid CIDR prefix")
+	}
+	return prefix, nil
+}
+
+// ReadSize returns the value to a given section and key as a number of bytes.
+// Values consist of a number and an optional unit: B, KB, MB, GB, TB and PB are powers of 1000,
+// KiB, MiB, GiB, TiB and PiB as well as the short forms K, M, G, T and P are powers of 1024.
+// Units are case-insensitive, e.g. 10MB, 512KiB or 2G.
+// An error will be returned if a key or section does not exist or the value is not a valid size.
+func (conf *Conf) ReadSize(section, key string) (int64, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return 0, err
+	}
+	size, ok := parseSize(value)
+	if !ok {
+		return 0, conf.valueError("readsize", section, key, value, "a valid size")
+	}
+	return size, nil
+}
+
+// ReadRegexp returns the value to a given section and key compiled as a regular expression.
+// An error will be returned if a key or section does not exist or the value does not compile.
+func (conf *Conf) ReadRegexp(section, key string) (*regexp.Regexp, error) {
+	value, err := conf.Read(section, key)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return nil, errors.New(at("readregexp", conf.where(section, key)) + " key \"" + key + "\" in section \"" + section + "\" is not a valid regular expression: " + err.Error())
+	}
+	return re, nil
+}
+
+// ReadIntDefault is like ReadInt but returns def if the key or section does not exist.
+func (conf *Conf) ReadIntDefault(section, key string, def int) (int, error) {
+	if _, exists := conf.lookup(section, key); !exists {
+		return def, nil
+	}
+	return conf.ReadInt(section, key)
+}
+
+// ReadInt64Default is like ReadInt64 but returns def if the key or section does not exist.
+func (conf *Conf) ReadInt64Default(section, key string, def int64) (int64, error) {
+	if _, exists := conf.lookup(section, key); !exists {
+		return def, nil
+	}
+	return conf.ReadInt64(section, key)
+}
+
+// ReadBoolDefault is like ReadBool but returns def if the key or section does not exist.
+func (conf *Conf) ReadBoolDefault(section, key string, def bool) (bool, error) {
+	if _, exists := conf.lookup(section, key); !exists {
+		return def, nil
+	}
+	return conf.ReadBool(section, key)
+}
+
+// ReadFloat64Default is like ReadFloat64 but returns def if the key or section does not exist.
+func (conf *Conf) ReadFloat64Default(section, key string, def float64) (float64, error) {
+	if _, exists := conf.lookup(section, key); !exists {
+		return def, nil
+	}
+	return conf.ReadFloat64(section, key)
+}
+
+// ReadDurationDefault is like ReadDuration but returns def if the key or section does not exist.
+func (conf *Conf) ReadDurationDefault(section, key string, def time.Duration) (time.Duration, error) {
+	if _, exists := conf.lookup(section, key); !exists {
+		return def, nil
+	}
+	return conf.ReadDuration(section, key)
+}
+
+// MustReadInt is like ReadInt but panics on error.
+func (conf *Conf) MustReadInt(section, key string) int {
+	i, err := conf.ReadInt(section, key)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// MustReadInt64 is like ReadInt64 but panics on error.
+func (conf *Conf) MustReadInt64(section, key string) int64 {
+	i, err := conf.ReadInt64(section, key)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// MustReadBool is like ReadBool but panics on error.
+func (conf *Conf) MustReadBool(section, key string) bool {
+	b, err := conf.ReadBool(section, key)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// MustReadFloat64 is like ReadFloat64 but panics on error.
+func (conf *Conf) MustReadFloat64(section, key string) float64 {
+	f, err := conf.ReadFloat64(section, key)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// MustReadDuration is like ReadDuration but panics on error.
+func (conf *Conf) MustReadDuration(section, key string) time.Duration {
+	d, err := conf.ReadDuration(section, key)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Get returns the value to a given section and key converted to T using the matching typed getter.
+// Supported types are string, int, int64, bool, float64, time.Duration, time.Time, []string, []int,
+// *url.URL, netip.Addr, netip.Prefix and *regexp.Regexp.
+func Get[T any](conf *Conf, section, key string) (T, error) {
+	var v T
+	var err error
+	switch p := any(&v).(type) {
+	case *string:
+		*p, err = conf.Read(section, key)
+	case *int:
+		*p, err = conf.ReadInt(section, key)
+	case *int64:
+		*p, err = conf.ReadInt64(section, key)
+	case *bool:
+		*p, err = conf.ReadBool(section, key)
+	case *float64:
+		*p, err = conf.ReadFloat64(section, key)
+	case *time.Duration:
+		*p, err = conf.ReadDuration(section, key)
+	case *time.Time:
+		*p, err = conf.ReadTime(section, key)
+	case *[]string:
+		*p, err = conf.ReadStringSlice(section, key)
+	case *[]int:
+		*p, err = conf.ReadIntSlice(section, key)
+	case **url.URL:
+		*p, err = conf.ReadURL(section, key)
+	case *netip.Addr:
+		*p, err = conf.ReadIP(section, key)
+	case *netip.Prefix:
+		*p, err = conf.ReadCIDR(section, key)
+	case **regexp.Regexp:
+		*p, err = conf.ReadRegexp(section, key)
+	default:
+		err = errors.New("get: unsupported type " + reflect.TypeOf(&v).Elem().String())
+	}
+	return v, err
+}
+
+// valueError builds the error returned by typed getters when a value cannot be converted.
+func (conf *Conf) valueError(op, section, key, value, want string) error {
+	return errors.New(at(op, conf.where(section, key)) + " key \"" + key + "\" in section \"" + section + "\" is not " + want + ": \"" + value + "\"")
+}
+
+// splitList splits value on sep and trims the elements.
+func splitList(value, sep string) []string {
+	if strings.TrimSpace(value) == "" {
+		return []string{}
+	}
+	list := strings.Split(value, sep)
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+	return list
+}
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"k":   1 << 10,
+	"m":   1 << 20,
+	"g":   1 << 30,
+	"t":   1 << 40,
+	"p":   1 << 50,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+// parseSize parses a human-readable byte size as described by ReadSize.
+func parseSize(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	i := strings.IndexFunc(value, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(value)
+	}
+	number, unit := value[:i], strings.ToLower(strings.TrimSpace(value[i:]))
+	multiplier, ok := sizeUnits[unit]
+	if !ok || number == "" {
+		return 0, false
+	}
+	if !strings.Contains(number, ".") {
+		n, err := strconv.ParseInt(number, 10, 64)
+		if err != nil || n > math.MaxInt64/multiplier {
+			return 0, false
+		}
+		return n * multiplier, true
+	}
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil || f*float64(multiplier) >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f * float64(multiplier)), true
+}
@@ -0,0 +1,371 @@
+package conf
+
+import (
+	"bytes"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseTest parses input or fails the test.
+func parseTest(t *testing.T, input string, opts ...Option) *Conf {
+	t.Helper()
+	conf, err := ParseString(input, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conf
+}
+
+func TestReadInt(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+		ok    bool
+	}{
+		{"42", 42, true},
+		{"-7", -7, true},
+		{"9223372036854775807", 9223372036854775807, true},
+		{"1.5", 0, false},
+		{"x", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		conf := parseTest(t, "[s]\nk="+tt.value+"\n")
+		got, err := conf.ReadInt64("s", "k")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ReadInt64(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+		if tt.want == int64(int(tt.want)) {
+			got, err := conf.ReadInt("s", "k")
+			if (err == nil) != tt.ok || int64(got) != tt.want {
+				t.Errorf("ReadInt(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+			}
+		}
+	}
+	if _, err := parseTest(t, "[s]\n").ReadInt("s", "k"); err == nil {
+		t.Error("ReadInt of a missing key returned no error")
+	}
+}
+
+func TestReadBool(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+		ok    bool
+	}{
+		{"true", true, true},
+		{"Yes", true, true},
+		{"ON", true, true},
+		{"1", true, true},
+		{"FALSE", false, true},
+		{"no", false, true},
+		{"off", false, true},
+		{"0", false, true},
+		{"maybe", false, false},
+		{"", false, false},
+	}
+	for _, tt := range tests {
+		got, err := parseTest(t, "[s]\nk="+tt.value+"\n").ReadBool("s", "k")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ReadBool(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestReadFloat64(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"1.5", 1.5, true},
+		{"-2e3", -2000, true},
+		{"7", 7, true},
+		{"1,5", 0, false},
+		{"x", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseTest(t, "[s]\nk="+tt.value+"\n").ReadFloat64("s", "k")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ReadFloat64(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestReadDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"30s", 30 * time.Second, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"-5ms", -5 * time.Millisecond, true},
+		{"5", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseTest(t, "[s]\nk="+tt.value+"\n").ReadDuration("s", "k")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ReadDuration(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestReadTime(t *testing.T) {
+	tests := []struct {
+		value   string
+		layouts []string
+		want    time.Time
+		ok      bool
+	}{
+		{"2024-05-01T10:00:00Z", nil, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), true},
+		{"2024-05-01", []string{time.Kitchen, time.DateOnly}, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), true},
+		{"2024-05-01", nil, time.Time{}, false},
+		{"yesterday", []string{time.DateOnly}, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, err := parseTest(t, "[s]\nk="+tt.value+"\n").ReadTime("s", "k", tt.layouts...)
+		if (err == nil) != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("ReadTime(%q, %q) = %v, %v, want %v", tt.value, tt.layouts, got, err, tt.want)
+		}
+	}
+}
+
+func TestReadStringSlice(t *testing.T) {
+	tests := []struct {
+		value string
+		sep   string
+		want  []string
+	}{
+		{"a, b ,c", ",", []string{"a", "b", "c"}},
+		{"a", ",", []string{"a"}},
+		{"", ",", []string{}},
+		{"a,,b", ",", []string{"a", "", "b"}},
+		{"a | b,c", "|", []string{"a", "b,c"}},
+	}
+	for _, tt := range tests {
+		got, err := parseTest(t, "[s]\nk="+tt.value+"\n").ReadStringSliceSep("s", "k", tt.sep)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadStringSliceSep(%q, %q) = %q, %v, want %q", tt.value, tt.sep, got, err, tt.want)
+		}
+	}
+	got, err := parseTest(t, "[s]\nk=a, b\n").ReadStringSlice("s", "k")
+	if want := []string{"a", "b"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadStringSlice = %q, %v, want %q", got, err, want)
+	}
+}
+
+func TestReadIntSlice(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []int
+		err   string
+	}{
+		{"1, 2,3", []int{1, 2, 3}, ""},
+		{"", []int{}, ""},
+		{"1,x", nil, "at index 1"},
+		{"1.5", nil, "at index 0"},
+	}
+	for _, tt := range tests {
+		got, err := parseTest(t, "[s]\nk="+tt.value+"\n").ReadIntSlice("s", "k")
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ReadIntSlice(%q) error = %v, want it to contain %q", tt.value, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ReadIntSlice(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestReadBytes(t *testing.T) {
+	hex := func(conf *Conf) ([]byte, error) { return conf.ReadBytesHex("s", "k") }
+	base64 := func(conf *Conf) ([]byte, error) { return conf.ReadBytesBase64("s", "k") }
+	tests := []struct {
+		name  string
+		read  func(conf *Conf) ([]byte, error)
+		value string
+		want  []byte
+		ok    bool
+	}{
+		{"hex", hex, "0aff", []byte{0x0a, 0xff}, true},
+		{"hex upper", hex, "0AFF", []byte{0x0a, 0xff}, true},
+		{"hex odd", hex, "abc", nil, false},
+		{"hex invalid", hex, "zz", nil, false},
+		{"base64 padded", base64, "aGk=", []byte("hi"), true},
+		{"base64 unpadded", base64, "aGk", []byte("hi"), true},
+		{"base64 invalid", base64, "a-b_", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.read(parseTest(t, "[s]\nk="+tt.value+"\n"))
+			if (err == nil) != tt.ok || !bytes.Equal(got, tt.want) {
+				t.Errorf("read %q = %x, %v, want %x", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadURL(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+		abs   bool
+	}{
+		{"https://example.com/a?b=c", true, true},
+		{"/relative/path", true, false},
+		{"%zz", false, false},
+	}
+	for _, tt := range tests {
+		conf := parseTest(t, "[s]\nk="+tt.value+"\n")
+		u, err := conf.ReadURL("s", "k")
+		if (err == nil) != tt.ok || err == nil && u.String() != tt.value {
+			t.Errorf("ReadURL(%q) = %v, %v", tt.value, u, err)
+		}
+		if _, err := conf.ReadAbsURL("s", "k"); (err == nil) != tt.abs {
+			t.Errorf("ReadAbsURL(%q) error = %v, want error %v", tt.value, err, !tt.abs)
+		}
+	}
+}
+
+func TestReadIP(t *testing.T) {
+	conf := parseTest(t, "[s]\nv4=10.0.0.1\nv6=::1\nnet=10.0.0.0/8\nbad=10.0.0.256\n")
+	if got, err := conf.ReadIP("s", "v4"); err != nil || got != netip.MustParseAddr("10.0.0.1") {
+		t.Errorf("ReadIP(v4) = %v, %v", got, err)
+	}
+	if got, err := conf.ReadIP("s", "v6"); err != nil || got != netip.IPv6Loopback() {
+		t.Errorf("ReadIP(v6) = %v, %v", got, err)
+	}
+	if got, err := conf.ReadCIDR("s", "net"); err != nil || got != netip.MustParsePrefix("10.0.0.0/8") {
+		t.Errorf("ReadCIDR(net) = %v, %v", got, err)
+	}
+	for _, key := range []string{"bad", "net"} {
+		if _, err := conf.ReadIP("s", key); err == nil {
+			t.Errorf("ReadIP(%s) returned no error", key)
+		}
+	}
+	for _, key := range []string{"bad", "v4"} {
+		if _, err := conf.ReadCIDR("s", key); err == nil {
+			t.Errorf("ReadCIDR(%s) returned no error", key)
+		}
+	}
+}
+
+func TestReadSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+		ok    bool
+	}{
+		{"100", 100, true},
+		{"100B", 100, true},
+		{"10MB", 10e6, true},
+		{"10 mb", 10e6, true},
+		{"512KiB", 512 << 10, true},
+		{"2G", 2 << 30, true},
+		{"1.5k", 1536, true},
+		{"10XB", 0, false},
+		{"MB", 0, false},
+		{"9999999P", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseTest(t, "[s]\nk="+tt.value+"\n").ReadSize("s", "k")
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ReadSize(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestReadRegexp(t *testing.T) {
+	conf := parseTest(t, "[s]\nvalid=^a+b$\ninvalid=a(b\n")
+	re, err := conf.ReadRegexp("s", "valid")
+	if err != nil || !re.MatchString("aab") || re.MatchString("ab!") {
+		t.Errorf("ReadRegexp(valid) = %v, %v", re, err)
+	}
+	if _, err := conf.ReadRegexp("s", "invalid"); err == nil || !strings.Contains(err.Error(), "regular expression") {
+		t.Errorf("ReadRegexp(invalid) error = %v", err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	conf := parseTest(t, "[s]\nn=42\nlist=a, b\nip=::1\nd=2s\n")
+	if got, err := Get[int](conf, "s", "n"); err != nil || got != 42 {
+		t.Errorf("Get[int] = %v, %v", got, err)
+	}
+	if got, err := Get[string](conf, "s", "n"); err != nil || got != "42" {
+		t.Errorf("Get[string] = %q, %v", got, err)
+	}
+	if got, err := Get[[]string](conf, "s", "list"); err != nil || !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("Get[[]string] = %q, %v", got, err)
+	}
+	if got, err := Get[netip.Addr](conf, "s", "ip"); err != nil || got != netip.IPv6Loopback() {
+		t.Errorf("Get[netip.Addr] = %v, %v", got, err)
+	}
+	if got, err := Get[time.Duration](conf, "s", "d"); err != nil || got != 2*time.Second {
+		t.Errorf("Get[time.Duration] = %v, %v", got, err)
+	}
+	if _, err := Get[bool](conf, "s", "list"); err == nil {
+		t.Error("Get[bool] of an invalid value returned no error")
+	}
+	if _, err := Get[complex64](conf, "s", "n"); err == nil || !strings.Contains(err.Error(), "unsupported type complex64") {
+		t.Errorf("Get[complex64] error = %v", err)
+	}
+}
+
+func TestReadDefaults(t *testing.T) {
+	conf := parseTest(t, "[s]\nn=7\nbad=x\nempty=\n")
+	if got := conf.ReadDefault("s", "missing", "def"); got != "def" {
+		t.Errorf("ReadDefault(missing) = %q", got)
+	}
+	if got := conf.ReadDefault("s", "empty", "def"); got != "" {
+		t.Errorf("ReadDefault(empty) = %q, want the empty value", got)
+	}
+	if got, err := conf.ReadIntDefault("s", "n", 1); err != nil || got != 7 {
+		t.Errorf("ReadIntDefault(n) = %v, %v", got, err)
+	}
+	if got, err := conf.ReadIntDefault("missing", "n", 1); err != nil || got != 1 {
+		t.Errorf("ReadIntDefault(missing) = %v, %v", got, err)
+	}
+	if _, err := conf.ReadIntDefault("s", "bad", 1); err == nil {
+		t.Error("ReadIntDefault of an invalid value returned no error")
+	}
+	if got, err := conf.ReadBoolDefault("s", "missing", true); err != nil || !got {
+		t.Errorf("ReadBoolDefault(missing) = %v, %v", got, err)
+	}
+	if got, err := conf.ReadDurationDefault("s", "missing", time.Minute); err != nil || got != time.Minute {
+		t.Errorf("ReadDurationDefault(missing) = %v, %v", got, err)
+	}
+}
+
+func TestMustRead(t *testing.T) {
+	conf := parseTest(t, "[s]\nn=7\nbad=x\n")
+	if got := conf.MustReadInt("s", "n"); got != 7 {
+		t.Errorf("MustReadInt(n) = %v", got)
+	}
+	tests := []struct {
+		name string
+		read func()
+	}{
+		{"missing", func() { conf.MustRead("s", "missing") }},
+		{"invalid int", func() { conf.MustReadInt("s", "bad") }},
+		{"invalid bool", func() { conf.MustReadBool("s", "bad") }},
+		{"invalid duration", func() { conf.MustReadDuration("s", "bad") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			tt.read()
+		})
+	}
+}
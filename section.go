@@ -0,0 +1,225 @@
+package conf
+
+import (
+	"strings"
+	"time"
+)
+
+// ChildSections returns the names of the direct child sections of parent in sorted order.
+// Section names are nested with dots, [server.tls] is a child of [server] whether or not [server] exists.
+// The children of "" are the top-level sections.
+func (conf *Conf) ChildSections(parent string) []string {
+	prefix := parent + "."
+	if parent == "" {
+		prefix = ""
+	}
+	children := []string{}
+	for _, section := range sortedKeys(conf.data) {
+		name, ok := strings.CutPrefix(section, prefix)
+		if !ok && conf.opts.caseInsensitive && len(section) >= len(prefix) && strings.EqualFold(section[:len(prefix)], prefix) {
+			name, ok = section[len(prefix):], true
+		}
+		if ok && name != "" && !strings.Contains(name, ".") {
+			children = append(children, section)
+		}
+	}
+	return children
+}
+
+// ReadPath returns the value to a dotted path of section and key, e.g. "server.tls.cert"
+// reads the key "cert" in section "server.tls". A path without dots reads a global key.
+// An error will be returned if a key or section does not exist.
+func (conf *Conf) ReadPath(path string) (string, error) {
+	i := strings.LastIndex(path, ".")
+	if i < 0 {
+		return conf.Read("", path)
+	}
+	return conf.Read(path[:i], path[i+1:])
+}
+
+// Sections returns the names of all sections in file order, sections added later follow in sorted order.
+// Global keys are not part of a section, list them with Keys("").
+func (conf *Conf) Sections() []string {
+	sections := []string{}
+	seen := map[string]bool{"": true}
+	for _, it := range conf.layout {
+		if _, exists := conf.data[it.section]; exists && !seen[it.section] {
+			seen[it.section] = true
+			sections = append(sections, it.section)
+		}
+	}
+	for _, section := range sortedKeys(conf.data) {
+		if !seen[section] {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// Keys returns the keys of a section in file order, keys added later follow in sorted order.
+// An error wrapping ErrSectionNotFound will be returned if the section does not exist.
+func (conf *Conf) Keys(section string) ([]string, error) {
+	section, exists := findSection(conf.data, section, conf.opts.caseInsensitive)
+	if !exists {
+		return nil, &notFoundError{at("keys", conf.filename) + " section \"" + section + "\" does not exist", []error{ErrSectionNotFound}}
+	}
+	keys := []string{}
+	seen := make(map[string]bool)
+	for _, it := range conf.layout {
+		if it.kind != itemKey || it.section != section || seen[it.key] {
+			continue
+		}
+		if _, exists := conf.data[section][it.key]; exists {
+			seen[it.key] = true
+			keys = append(keys, it.key)
+		}
+	}
+	for _, key := range sortedKeys(conf.data[section]) {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Len returns the number of sections like len(conf.Sections()), global keys are not counted as a section.
+func (conf *Conf) Len() int {
+	if _, exists := conf.data[""]; exists {
+		return len(conf.data) - 1
+	}
+	return len(conf.data)
+}
+
+// SectionLen returns the number of keys in a section, 0 if the section does not exist.
+func (conf *Conf) SectionLen(section string) int {
+	section, _ = findSection(conf.data, section, conf.opts.caseInsensitive)
+	return len(conf.data[section])
+}
+
+// Walk calls fn for every key in file order, global keys first, and stops at the first error, which is returned.
+// List keys are visited once per element. Values are read like Read, so errors of Interpolate are returned as well.
+func (conf *Conf) Walk(fn func(section, key, value string) error) error {
+	for _, section := range append([]string{""}, conf.Sections()...) {
+		keys, err := conf.Keys(section)
+		if err != nil {
+			continue
+		}
+		for _, key := range keys {
+			values, err := conf.ReadSlice(section, key)
+			if err != nil {
+				return err
+			}
+			for _, value := range values {
+				if err := fn(section, key, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// SubsectionNames returns the subsection names of the sections named like [remote "origin"] for section "remote"
+// in file order, as used by git config files. Quotes and backslashes in the names are unescaped.
+func (conf *Conf) SubsectionNames(section string) []string {
+	names := []string{}
+	for _, name := range conf.Sections() {
+		if subsection, ok := conf.subsection(name, section); ok {
+			names = append(names, subsection)
+		}
+	}
+	return names
+}
+
+// Section is a handle to a single section of a conf, its getters read keys of that section.
+// It reflects later changes to the conf.
+type Section struct {
+	conf *Conf
+	name string
+}
+
+// Section returns a handle to a section.
+// An error wrapping ErrSectionNotFound will be returned if the section does not exist.
+func (conf *Conf) Section(name string) (*Section, error) {
+	section, exists := findSection(conf.data, name, conf.opts.caseInsensitive)
+	if !exists {
+		return nil, &notFoundError{at("section", conf.filename) + " section \"" + name + "\" does not exist", []error{ErrSectionNotFound}}
+	}
+	return &Section{conf, section}, nil
+}
+
+// Name returns the name of the section.
+func (s *Section) Name() string {
+	return s.name
+}
+
+// Read is like Conf.Read for a key of the section.
+func (s *Section) Read(key string) (string, error) {
+	return s.conf.Read(s.name, key)
+}
+
+// ReadSlice is like Conf.ReadSlice for a key of the section.
+func (s *Section) ReadSlice(key string) ([]string, error) {
+	return s.conf.ReadSlice(s.name, key)
+}
+
+// ReadDefault is like Conf.ReadDefault for a key of the section.
+func (s *Section) ReadDefault(key, def string) string {
+	return s.conf.ReadDefault(s.name, key, def)
+}
+
+// ReadInt is like Conf.ReadInt for a key of the section.
+func (s *Section) ReadInt(key string) (int, error) {
+	return s.conf.ReadInt(s.name, key)
+}
+
+// ReadInt64 is like Conf.ReadInt64 for a key of the section.
+func (s *Section) ReadInt64(key string) (int64, error) {
+	return s.conf.ReadInt64(s.name, key)
+}
+
+// ReadBool is like Conf.ReadBool for a key of the section.
+func (s *Section) ReadBool(key string) (bool, error) {
+	return s.conf.ReadBool(s.name, key)
+}
+
+// ReadFloat64 is like Conf.ReadFloat64 for a key of the section.
+func (s *Section) ReadFloat64(key string) (float64, error) {
+	return s.conf.ReadFloat64(s.name, key)
+}
+
+// ReadDuration is like Conf.ReadDuration for a key of the section.
+func (s *Section) ReadDuration(key string) (time.Duration, error) {
+	return s.conf.ReadDuration(s.name, key)
+}
+
+// HasKey reports whether a key exists in the section.
+func (s *Section) HasKey(key string) bool {
+	return s.conf.HasKey(s.name, key)
+}
+
+// Keys returns the keys of the section in file order, see Conf.Keys.
+func (s *Section) Keys() ([]string, error) {
+	return s.conf.Keys(s.name)
+}
+
+// Len returns the number of keys in the section.
+func (s *Section) Len() int {
+	return s.conf.SectionLen(s.name)
+}
+
+// Unmarshal is like Conf.UnmarshalSection for the section.
+func (s *Section) Unmarshal(v any) error {
+	return s.conf.UnmarshalSection(s.name, v)
+}
+
+// Subsection returns a handle to the section named like [remote "origin"] for section "remote" and name "origin".
+// An error wrapping ErrSectionNotFound will be returned if the subsection does not exist.
+func (conf *Conf) Subsection(section, name string) (*Section, error) {
+	for _, s := range conf.Sections() {
+		if subsection, ok := conf.subsection(s, section); ok && subsection == name {
+			return &Section{conf, s}, nil
+		}
+	}
+	return nil, &notFoundError{at("subsection", conf.filename) + " subsection \"" + name + "\" of section \"" + section + "\" does not exist", []error{ErrSectionNotFound}}
+}
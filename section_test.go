@@ -0,0 +1,26 @@
+package conf
+
+import "testing"
+
+func TestCaseInsensitiveSubsections(t *testing.T) {
+	conf, err := ParseString("[remote \"a\"]\nurl=lower\n[remote \"A\"]\nurl=upper\n", CaseInsensitive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := conf.ReadDefault("REMOTE \"a\"", "URL", ""); got != "lower" {
+		t.Errorf("Read(REMOTE \"a\") = %q, want %q", got, "lower")
+	}
+	if got := conf.ReadDefault("Remote \"A\"", "url", ""); got != "upper" {
+		t.Errorf("Read(Remote \"A\") = %q, want %q", got, "upper")
+	}
+	s, err := conf.Subsection("Remote", "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Read("url"); got != "upper" {
+		t.Errorf("Subsection(Remote, A).Read(url) = %q, want %q", got, "upper")
+	}
+	if _, err := ParseString("[remote \"a\"]\n[REMOTE \"a\"]\n", CaseInsensitive()); err == nil {
+		t.Error("sections differing in the case of their name are no duplicates")
+	}
+}
@@ -0,0 +1,83 @@
+package conf
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+)
+
+// snapshotVersion is incremented whenever the snapshot format changes.
+const snapshotVersion = 2
+
+// snapshot is the encoded form of a conf.
+type snapshot struct {
+	Version  int
+	Filename string
+	Data     map[string]map[string]string
+	Lists    map[string]map[string][]string
+	Parents  map[string]string
+	Layout   []snapshotItem
+	// IncludedData and IncludedLists hold the keys read from included files.
+	IncludedData  map[string]map[string]string
+	IncludedLists map[string]map[string][]string
+}
+
+// snapshotItem is the encoded form of an item.
+type snapshotItem struct {
+	Kind     int
+	Section  string
+	Key      string
+	List     bool
+	Shadowed bool
+	Value    string
+	Text     string
+	Line     int
+}
+
+// EncodeSnapshot writes the parsed conf to w in a binary form that DecodeSnapshot loads without parsing,
+// e.g. to cache large confs that rarely change. The filename, layout and included keys are kept, options are not.
+func (conf *Conf) EncodeSnapshot(w io.Writer) error {
+	s := snapshot{Version: snapshotVersion, Filename: conf.filename, Data: conf.data, Lists: conf.lists, Parents: conf.parents, Layout: make([]snapshotItem, len(conf.layout))}
+	for i, it := range conf.layout {
+		s.Layout[i] = snapshotItem{it.kind, it.section, it.key, it.list, it.shadowed, it.value, it.text, it.line}
+	}
+	if conf.included != nil {
+		s.IncludedData, s.IncludedLists = conf.included.data, conf.included.lists
+	}
+	return gob.NewEncoder(w).Encode(s)
+}
+
+// DecodeSnapshot loads a conf written by EncodeSnapshot from r.
+// Options only used while parsing have no effect, options used while reading like Interpolate or
+// CaseInsensitive must be passed again. An error will be returned if the snapshot was written
+// by an incompatible version of this package.
+func DecodeSnapshot(r io.Reader, opts ...Option) (*Conf, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	if s.Version != snapshotVersion {
+		return nil, errors.New("decodesnapshot: unsupported snapshot version")
+	}
+	conf := &Conf{filename: s.Filename, data: s.Data, lists: s.Lists, parents: s.Parents, opts: options{delimiters: "=", comments: defaultComments}}
+	if conf.data == nil {
+		conf.data = make(map[string]map[string]string)
+	}
+	if conf.lists == nil {
+		conf.lists = make(map[string]map[string][]string)
+	}
+	for _, opt := range opts {
+		opt(&conf.opts)
+	}
+	if s.IncludedData != nil {
+		conf.included = &Conf{data: s.IncludedData, lists: s.IncludedLists, opts: conf.opts}
+		if conf.included.lists == nil {
+			conf.included.lists = make(map[string]map[string][]string)
+		}
+	}
+	conf.layout = make([]item, len(s.Layout))
+	for i, it := range s.Layout {
+		conf.layout[i] = item{it.Kind, it.Section, it.Key, it.List, it.Shadowed, it.Value, it.Text, it.Line}
+	}
+	return conf, nil
+}
@@ -0,0 +1,83 @@
+package conf
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	input := "; comment\ng=1\n[a : base]\nk=v ; inline\nlist[]=x\nlist[]=y\n\n[base]\nj=2\n"
+	conf := parseTest(t, input, AllowGlobalKeys(), Inheritance())
+	var buf bytes.Buffer
+	if err := conf.EncodeSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeSnapshot(&buf, Inheritance())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Map(), conf.Map()) {
+		t.Errorf("Map = %v, want %v", decoded.Map(), conf.Map())
+	}
+	if got := decoded.ReadAll("a", "list"); !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Errorf("ReadAll(a, list) = %q", got)
+	}
+	if got := decoded.ReadDefault("a", "j", ""); got != "2" {
+		t.Errorf("inherited a.j = %q, want %q", got, "2")
+	}
+	var out bytes.Buffer
+	if _, err := decoded.WriteTo(&out); err != nil || out.String() != input {
+		t.Errorf("WriteTo = %q, %v, want the parsed layout %q", out.String(), err, input)
+	}
+}
+
+func TestSnapshotIncludes(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.conf")
+	if err := os.WriteFile(filepath.Join(dir, "inc.conf"), []byte("[a]\ny=2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(main, []byte("include=inc.conf\n[a]\nx=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := Open(main, Includes(), AllowGlobalKeys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := conf.EncodeSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded.Set("a", "z", "3")
+	if err := decoded.Save(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(main)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "include=inc.conf\n[a]\nx=1\nz=3\n"; string(b) != want {
+		t.Errorf("saved %q, want %q", b, want)
+	}
+}
+
+func TestSnapshotVersion(t *testing.T) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(snapshot{Version: snapshotVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeSnapshot(&buf); err == nil {
+		t.Error("DecodeSnapshot of another version returned no error")
+	}
+	if _, err := DecodeSnapshot(bytes.NewReader([]byte("not a snapshot"))); err == nil {
+		t.Error("DecodeSnapshot of garbage returned no error")
+	}
+}
@@ -0,0 +1,471 @@
+// Package toml converts confs to TOML documents and back.
+// It supports the two-level subset of TOML a conf maps to without depending on a TOML library:
+//
+//	global = "key before the first table"
+//	list = ["global", "list key"]
+//
+//	[section]
+//	key = "value"
+//
+//	[server.tls]
+//	cert = "dotted section names are nested tables"
+//
+// Keys before the first table are global keys, tables are sections and arrays become list keys.
+// Dotted keys are read into the section of their table joined with the dotted prefix.
+// Inline tables, arrays of tables and nested arrays are not supported.
+package toml
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hirsch/conf"
+)
+
+// ToTOML returns the sections and keys of c as a TOML document in file order.
+// Values are written as strings, list keys as arrays of strings.
+// An error will be returned if a key has the name of a table, e.g. the global key server and the section [server.tls].
+func ToTOML(c *conf.Conf) ([]byte, error) {
+	b, err := c.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	var out strings.Builder
+	globals, _ := c.Keys("")
+	for _, key := range globals {
+		writeKey(&out, key, m[""][key])
+	}
+	for _, section := range c.Sections() {
+		for i := 0; i <= len(section); i++ {
+			if i < len(section) && section[i] != '.' {
+				continue
+			}
+			parent, child := "", section[:i]
+			if j := strings.LastIndex(child, "."); j >= 0 {
+				parent, child = child[:j], child[j+1:]
+			}
+			if _, exists := m[parent][child]; exists {
+				return nil, errors.New("totoml: key \"" + child + "\" in section \"" + parent + "\" has the name of a table")
+			}
+		}
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString("[" + tableName(section) + "]\n")
+		keys, _ := c.Keys(section)
+		for _, key := range keys {
+			writeKey(&out, key, m[section][key])
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+// writeKey writes a key with a string value or an array of values.
+func writeKey(out *strings.Builder, key string, value any) {
+	list, isList := value.([]any)
+	if !isList {
+		out.WriteString(keyName(key) + " = " + quote(value.(string)) + "\n")
+		return
+	}
+	elems := make([]string, len(list))
+	for i, elem := range list {
+		elems[i] = quote(elem.(string))
+	}
+	out.WriteString(keyName(key) + " = [" + strings.Join(elems, ", ") + "]\n")
+}
+
+// tableName returns a section name as dotted table name, or quoted if it has empty parts.
+func tableName(section string) string {
+	parts := strings.Split(section, ".")
+	for i, part := range parts {
+		if part == "" {
+			return quote(section)
+		}
+		parts[i] = keyName(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// keyName returns key as a bare key if possible, quoted otherwise.
+func keyName(key string) string {
+	if key == "" {
+		return quote(key)
+	}
+	for i := 0; i < len(key); i++ {
+		if !isBare(key[i]) {
+			return quote(key)
+		}
+	}
+	return key
+}
+
+// isBare reports whether c may be part of a bare key.
+func isBare(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '_' || c == '-'
+}
+
+// quote returns s as a basic string.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < ' ' || r == 0x7f {
+				b.WriteString(`\u` + strconv.FormatInt(int64(r)+0x10000, 16)[1:])
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// parser reads a TOML document.
+type parser struct {
+	s    string
+	pos  int
+	line int
+}
+
+// FromTOML builds a conf from a TOML document.
+// Strings are read unescaped, numbers, booleans and dates as their text.
+func FromTOML(b []byte) (*conf.Conf, error) {
+	p := &parser{s: strings.ReplaceAll(string(b), "\r\n", "\n"), line: 1}
+	if !utf8.ValidString(p.s) {
+		return nil, errors.New("fromtoml: document is not valid UTF-8")
+	}
+	m := map[string]map[string]any{"": {}}
+	tables := make(map[string]bool)
+	section := ""
+	for {
+		p.skipBlank(true)
+		if p.pos == len(p.s) {
+			break
+		}
+		if p.peek("[") {
+			if p.peek("[[") {
+				return nil, p.error("arrays of tables are not supported")
+			}
+			p.pos++
+			p.skipBlank(false)
+			path, err := p.keyPath()
+			if err != nil {
+				return nil, err
+			}
+			if !p.peek("]") {
+				return nil, p.error("missing ] after table name")
+			}
+			p.pos++
+			section = strings.Join(path, ".")
+			if tables[section] {
+				return nil, p.error("duplicate table \"" + section + "\"")
+			}
+			tables[section] = true
+			if m[section] == nil {
+				m[section] = make(map[string]any)
+			}
+			if err := p.endLine(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		path, err := p.keyPath()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek("=") {
+			return nil, p.error("missing = after key")
+		}
+		p.pos++
+		p.skipBlank(false)
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		name, key := strings.Join(append([]string{section}, path[:len(path)-1]...), "."), path[len(path)-1]
+		if section == "" {
+			name = strings.Join(path[:len(path)-1], ".")
+		}
+		if m[name] == nil {
+			m[name] = make(map[string]any)
+		}
+		if _, exists := m[name][key]; exists {
+			return nil, p.error("duplicate key \"" + key + "\"")
+		}
+		m[name][key] = value
+		if err := p.endLine(); err != nil {
+			return nil, err
+		}
+	}
+	if len(m[""]) == 0 {
+		delete(m, "")
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return conf.FromJSON(b)
+}
+
+// keyPath parses a bare, quoted or dotted key followed by whitespace.
+func (p *parser) keyPath() ([]string, error) {
+	var path []string
+	for {
+		var part string
+		switch {
+		case p.peek("\""), p.peek("'"):
+			if p.peek(`"""`) || p.peek("'''") {
+				return nil, p.error("multi-line strings cannot be keys")
+			}
+			var err error
+			if part, err = p.str(); err != nil {
+				return nil, err
+			}
+		default:
+			start := p.pos
+			for p.pos < len(p.s) && isBare(p.s[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.error("missing key")
+			}
+			part = p.s[start:p.pos]
+		}
+		path = append(path, part)
+		p.skipBlank(false)
+		if !p.peek(".") {
+			return path, nil
+		}
+		p.pos++
+		p.skipBlank(false)
+	}
+}
+
+// value parses a string, array or any other value as its text.
+func (p *parser) value() (any, error) {
+	switch {
+	case p.peek("{"):
+		return nil, p.error("inline tables are not supported")
+	case !p.peek("["):
+		return p.scalar()
+	}
+	p.pos++
+	list := []string{}
+	for {
+		p.skipBlank(true)
+		if p.peek("]") {
+			p.pos++
+			return list, nil
+		}
+		if p.peek("[") || p.peek("{") {
+			return nil, p.error("nested arrays and inline tables are not supported")
+		}
+		value, err := p.scalar()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+		p.skipBlank(true)
+		switch {
+		case p.peek(","):
+			p.pos++
+		case !p.peek("]"):
+			return nil, p.error("missing , or ] in array")
+		}
+	}
+}
+
+// scalar parses a string or a bare value like a number, boolean or date.
+func (p *parser) scalar() (string, error) {
+	if p.peek("\"") || p.peek("'") {
+		return p.str()
+	}
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(" \t\n,]#", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	// A local date may be followed by a time after a space.
+	if p.pos-start == 10 && p.s[start+4] == '-' && p.peek(" ") && p.pos+1 < len(p.s) && '0' <= p.s[p.pos+1] && p.s[p.pos+1] <= '9' {
+		p.pos++
+		for p.pos < len(p.s) && !strings.ContainsRune(" \t\n,]#", rune(p.s[p.pos])) {
+			p.pos++
+		}
+	}
+	if p.pos == start {
+		return "", p.error("missing value")
+	}
+	return p.s[start:p.pos], nil
+}
+
+// str parses a basic, literal or multi-line string.
+func (p *parser) str() (string, error) {
+	delim := p.s[p.pos : p.pos+1]
+	if p.peek(delim + delim + delim) {
+		delim += delim + delim
+	}
+	p.pos += len(delim)
+	if len(delim) == 3 && p.peek("\n") {
+		p.pos++
+		p.line++
+	}
+	start := p.pos
+	for {
+		if p.pos == len(p.s) || len(delim) == 1 && p.s[p.pos] == '\n' {
+			return "", p.error("unterminated string")
+		}
+		if p.peek(delim) {
+			// Up to two quotes may precede the closing delimiter of a multi-line string.
+			for len(delim) == 3 && p.pos+3 < len(p.s) && p.s[p.pos+3] == delim[0] {
+				p.pos++
+			}
+			break
+		}
+		if p.s[p.pos] == '\\' && delim[0] == '"' {
+			p.pos++
+			if p.pos == len(p.s) {
+				return "", p.error("unterminated string")
+			}
+		}
+		if p.s[p.pos] == '\n' {
+			p.line++
+		}
+		p.pos++
+	}
+	text := p.s[start:p.pos]
+	p.pos += len(delim)
+	if delim[0] == '\'' {
+		return text, nil
+	}
+	value, ok := unescape(text)
+	if !ok {
+		return "", p.error("invalid escape in string")
+	}
+	return value, nil
+}
+
+// unescape replaces the escape sequences of a basic string.
+// A backslash at the end of a line trims the following whitespace and newlines.
+func unescape(s string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", false
+		}
+		switch s[i] {
+		case 'b':
+			b.WriteByte('\b')
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'f':
+			b.WriteByte('\f')
+		case 'r':
+			b.WriteByte('\r')
+		case 'e':
+			b.WriteByte(0x1b)
+		case '"', '\\':
+			b.WriteByte(s[i])
+		case 'u', 'U':
+			n := 4
+			if s[i] == 'U' {
+				n = 8
+			}
+			if i+n >= len(s) {
+				return "", false
+			}
+			r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(r)) {
+				return "", false
+			}
+			b.WriteRune(rune(r))
+			i += n
+		case ' ', '\t', '\n':
+			rest := strings.TrimLeft(s[i:], " \t")
+			if !strings.HasPrefix(rest, "\n") {
+				return "", false
+			}
+			rest = strings.TrimLeft(rest, " \t\n")
+			i = len(s) - len(rest) - 1
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// skipBlank skips whitespace and comments, and newlines if multiline is set.
+func (p *parser) skipBlank(multiline bool) {
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case ' ', '\t':
+		case '\n':
+			if !multiline {
+				return
+			}
+			p.line++
+		case '#':
+			if !multiline {
+				return
+			}
+			for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		default:
+			return
+		}
+		p.pos++
+	}
+}
+
+// endLine returns an error if anything but whitespace or a comment follows on the line.
+func (p *parser) endLine() error {
+	p.skipBlank(false)
+	if p.peek("#") {
+		for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+			p.pos++
+		}
+	}
+	if p.pos < len(p.s) && p.s[p.pos] != '\n' {
+		return p.error("unexpected text after value")
+	}
+	return nil
+}
+
+// peek reports whether the document continues with s.
+func (p *parser) peek(s string) bool {
+	return strings.HasPrefix(p.s[p.pos:], s)
+}
+
+// error returns an error for the current line.
+func (p *parser) error(msg string) error {
+	return errors.New("fromtoml: line " + strconv.Itoa(p.line) + ": " + msg)
+}
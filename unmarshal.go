@@ -0,0 +1,484 @@
+package conf
+
+import (
+	"encoding"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errUnsupported is returned by decode for types it cannot convert to.
+var errUnsupported = errors.New("unsupported type")
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Unmarshal stores the values of conf in the struct pointed to by v.
+// Fields are matched by their conf tag of the form "section.key", a tag without a dot names a global key.
+// Untagged fields use their lowercased name as global key and fields tagged "-" are skipped.
+// Supported field types are strings, bools, integers, floats, time.Duration, time.Time,
+// types implementing encoding.TextUnmarshaler or registered with DecodeHook and pointers and slices of those.
+// Slices are read from list keys or split on commas like ReadStringSlice.
+// Fields of keys that do not exist are left unchanged unless the tag has a default,
+// e.g. `conf:"server.port,default=8080"`. The default option must come last and may contain commas.
+// Keys tagged required, e.g. `conf:"db.dsn,required"`, must exist; an error listing all missing
+// required keys is returned, it wraps ErrKeyNotFound.
+//
+// Struct fields and pointers to structs are read from the section named like the field,
+// e.g. a field TLS TLSConfig from [tls]. Structs nested in those read child sections like [server.tls].
+// Pointers to structs are only allocated if their section exists.
+// The fields of untagged embedded structs are read as if they were fields of the embedding struct.
+//
+// Slices of structs are read from repeated sections with a quoted subsection name,
+// e.g. a field Upstreams []Upstream `conf:"upstream"` from [upstream "a"] and [upstream "b"].
+// The subsection name is stored in the string field of Upstream tagged `conf:",name"`.
+func (conf *Conf) Unmarshal(v any) error {
+	rv, err := structPointer("unmarshal", v)
+	if err != nil {
+		return err
+	}
+	return conf.unmarshal(rv, "", true)
+}
+
+// UnmarshalSection is like Unmarshal but reads the keys of the given section,
+// tags name a key of the section without a section part.
+func (conf *Conf) UnmarshalSection(section string, v any) error {
+	rv, err := structPointer("unmarshalsection", v)
+	if err != nil {
+		return err
+	}
+	return conf.unmarshal(rv, section, false)
+}
+
+// structPointer returns the struct v points to.
+func structPointer(op string, v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errors.New(op + ": argument must be a non-nil pointer to a struct")
+	}
+	return rv.Elem(), nil
+}
+
+// unmarshal stores the keys of section in the fields of the struct rv.
+// If dotted is set, tags may name another section with a "section." prefix.
+// The errors for all missing required keys are returned joined.
+func (conf *Conf) unmarshal(rv reflect.Value, section string, dotted bool) error {
+	var missing []error
+	if err := conf.decodeStruct(rv, section, dotted, &missing); err != nil {
+		return err
+	}
+	return errors.Join(missing...)
+}
+
+// decodeStruct is unmarshal adding the errors for missing required keys to missing.
+func (conf *Conf) decodeStruct(rv reflect.Value, section string, dotted bool, missing *[]error) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.Anonymous && field.Tag.Get("conf") == "" && conf.isSection(field.Type) {
+			v := rv.Field(i)
+			if v.Kind() == reflect.Pointer {
+				if v.IsNil() && !v.CanSet() {
+					continue
+				}
+				if v.IsNil() {
+					v.Set(reflect.New(v.Type().Elem()))
+				}
+				v = v.Elem()
+			}
+			if err := conf.decodeStruct(v, section, dotted, missing); err != nil {
+				return err
+			}
+			continue
+		}
+		tag := parseTag(field)
+		name := tag.name
+		if !field.IsExported() || name == "-" || tag.isName {
+			continue
+		}
+		if field.Type.Kind() == reflect.Slice && conf.isSection(field.Type.Elem()) {
+			if err := conf.decodeSections(rv.Field(i), childSection(section, name), missing); err != nil {
+				return err
+			}
+			continue
+		}
+		if conf.isSection(field.Type) {
+			if err := conf.decodeSection(rv.Field(i), childSection(section, name), missing); err != nil {
+				return err
+			}
+			continue
+		}
+		section, key := section, name
+		if i := strings.LastIndex(name, "."); dotted && i >= 0 {
+			section, key = name[:i], name[i+1:]
+		}
+		if !conf.HasKey(section, key) {
+			if tag.required {
+				*missing = append(*missing, conf.notFound("unmarshal", section, key))
+			}
+			if !tag.hasDefault {
+				continue
+			}
+			if err := conf.decodeDefault(rv.Field(i), section, key, tag.def); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := conf.decodeKey(rv.Field(i), section, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeSection stores the keys of section in the struct or pointer to a struct v.
+func (conf *Conf) decodeSection(v reflect.Value, section string, missing *[]error) error {
+	if v.Kind() != reflect.Pointer {
+		return conf.decodeStruct(v, section, false, missing)
+	}
+	if _, exists := findSection(conf.data, section, conf.opts.caseInsensitive); !exists {
+		return nil
+	}
+	if v.IsNil() {
+		v.Set(reflect.New(v.Type().Elem()))
+	}
+	return conf.decodeStruct(v.Elem(), section, false, missing)
+}
+
+// decodeSections stores the sections named like [name "subsection"] in file order in the slice v
+// of structs or pointers to structs. The subsection is stored in the string field tagged with the name option.
+func (conf *Conf) decodeSections(v reflect.Value, name string, missing *[]error) error {
+	slice := reflect.MakeSlice(v.Type(), 0, 0)
+	for _, section := range conf.Sections() {
+		subsection, ok := conf.subsection(section, name)
+		if !ok {
+			continue
+		}
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := conf.decodeSection(elem, section, missing); err != nil {
+			return err
+		}
+		fields := elem
+		if fields.Kind() == reflect.Pointer {
+			fields = fields.Elem()
+		}
+		for i := 0; i < fields.NumField(); i++ {
+			if parseTag(fields.Type().Field(i)).isName && fields.Field(i).Kind() == reflect.String {
+				fields.Field(i).SetString(subsection)
+			}
+		}
+		slice = reflect.Append(slice, elem)
+	}
+	if slice.Len() > 0 {
+		v.Set(slice)
+	}
+	return nil
+}
+
+// subsection returns the quoted subsection of a section named like [name "subsection"].
+func (conf *Conf) subsection(section, name string) (string, bool) {
+	if len(section) <= len(name) || section[len(name)] != ' ' && section[len(name)] != '\t' {
+		return "", false
+	}
+	if prefix := section[:len(name)]; prefix != name && !(conf.opts.caseInsensitive && strings.EqualFold(prefix, name)) {
+		return "", false
+	}
+	quoted := strings.TrimLeft(section[len(name):], " \t")
+	if len(quoted) < 2 || quoted[0] != '"' || quoted[len(quoted)-1] != '"' {
+		return "", false
+	}
+	return subsectionUnescaper.Replace(quoted[1 : len(quoted)-1]), true
+}
+
+var (
+	subsectionEscaper   = strings.NewReplacer("\\", "\\\\", "\"", "\\\"")
+	subsectionUnescaper = strings.NewReplacer("\\\\", "\\", "\\\"", "\"")
+)
+
+// isSection reports whether fields of type t map to a section rather than a key.
+func (conf *Conf) isSection(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	_, hooked := conf.opts.hooks[t]
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(textUnmarshalerType) && !hooked
+}
+
+// childSection returns the section of a struct field named name within section.
+func childSection(section, name string) string {
+	if section == "" {
+		return name
+	}
+	return section + "." + name
+}
+
+// fieldTag is the parsed conf tag of a struct field.
+type fieldTag struct {
+	name       string
+	def        string
+	hasDefault bool
+	required   bool
+	isName     bool
+}
+
+// parseTag parses the conf tag of a field of the form "name,option,...".
+// The name defaults to the lowercased field name. The default=value option must come last,
+// its value extends to the end of the tag and may contain commas.
+func parseTag(field reflect.StructField) fieldTag {
+	name, options, _ := strings.Cut(field.Tag.Get("conf"), ",")
+	tag := fieldTag{name: name}
+	if name == "" {
+		tag.name = strings.ToLower(field.Name)
+	}
+	for options != "" {
+		if def, ok := strings.CutPrefix(options, "default="); ok {
+			tag.def, tag.hasDefault = def, true
+			break
+		}
+		var option string
+		option, options, _ = strings.Cut(options, ",")
+		switch option {
+		case "required":
+			tag.required = true
+		case "name":
+			tag.isName = true
+		}
+	}
+	return tag
+}
+
+// decodeKey converts the value of a key and stores it in v.
+func (conf *Conf) decodeKey(v reflect.Value, section, key string) error {
+	values, err := conf.ReadSlice(section, key)
+	if err != nil {
+		return err
+	}
+	if conf.splits(v.Type()) && !conf.isList(section, key) {
+		values = splitList(values[0], ",")
+	}
+	value, t, err := conf.decodeAll(v, values)
+	return conf.decodeError(err, section, key, value, t)
+}
+
+// decodeDefault converts the default value of a field tag and stores it in v, slices are split on commas.
+func (conf *Conf) decodeDefault(v reflect.Value, section, key, def string) error {
+	values := []string{def}
+	if conf.splits(v.Type()) {
+		values = splitList(def, ",")
+	}
+	value, t, err := conf.decodeAll(v, values)
+	if err == nil || err == errUnsupported {
+		return conf.decodeError(err, section, key, value, t)
+	}
+	return errors.New("unmarshal: default of key \"" + key + "\" in section \"" + section + "\" is not a valid " + describe(t) + ": \"" + value + "\"")
+}
+
+// decodeAll stores all values in the slice v or the last value in v if it is no slice.
+// The value and type that could not be converted are returned with the error of decode.
+func (conf *Conf) decodeAll(v reflect.Value, values []string) (string, reflect.Type, error) {
+	if !conf.splits(v.Type()) {
+		value := values[len(values)-1]
+		return value, v.Type(), conf.decode(v, value)
+	}
+	slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := conf.decode(slice.Index(i), value); err != nil {
+			return value, v.Type().Elem(), err
+		}
+	}
+	v.Set(slice)
+	return "", v.Type(), nil
+}
+
+// splits reports whether a field of type t holds a list of values.
+// Slices implementing encoding.TextUnmarshaler like net.IP or registered with DecodeHook are decoded from a single value.
+func (conf *Conf) splits(t reflect.Type) bool {
+	_, hooked := conf.opts.hooks[t]
+	return t.Kind() == reflect.Slice && !reflect.PointerTo(t).Implements(textUnmarshalerType) && !hooked
+}
+
+// decodeError returns the error of Unmarshal for a value that could not be converted to t by decode.
+func (conf *Conf) decodeError(err error, section, key, value string, t reflect.Type) error {
+	switch {
+	case err == nil:
+		return nil
+	case err == errUnsupported:
+		return errors.New("unmarshal: unsupported type " + t.String() + " for key \"" + key + "\" in section \"" + section + "\"")
+	}
+	return conf.valueError("unmarshal", section, key, value, "a valid "+describe(t))
+}
+
+// describe names a type in error messages.
+func describe(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		return "duration"
+	case t == timeType:
+		return "time"
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "unsigned integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return t.String()
+}
+
+// decode converts value to the type of v and stores it in v.
+// A hook registered for the type with DecodeHook takes precedence over the built-in conversions.
+func (conf *Conf) decode(v reflect.Value, value string) error {
+	if hook, ok := conf.opts.hooks[v.Type()]; ok {
+		converted, err := hook(value)
+		if err != nil {
+			return err
+		}
+		v.Set(converted)
+		return nil
+	}
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if v.Kind() != reflect.Pointer && reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, ok := parseBool(value)
+		if !ok {
+			return errors.New("invalid boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := conf.decode(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+	default:
+		return errUnsupported
+	}
+	return nil
+}
+
+// Decode stores the values of conf in the target v points to: a struct like Unmarshal,
+// a map[string]map[string]string of sections with the global keys in section ""
+// or a map[string]any holding a map[string]any per section, list keys as []string and the global keys directly.
+// An error will be returned for a map[string]any if a global key has the name of a section.
+// Existing maps are added to, nil maps are allocated.
+func (conf *Conf) Decode(v any) error {
+	switch m := v.(type) {
+	case *map[string]map[string]string:
+		if *m == nil {
+			*m = make(map[string]map[string]string)
+		}
+		for section, keys := range conf.data {
+			if (*m)[section] == nil {
+				(*m)[section] = make(map[string]string, len(keys))
+			}
+			for key := range keys {
+				value, err := conf.Read(section, key)
+				if err != nil {
+					return err
+				}
+				(*m)[section][key] = value
+			}
+		}
+		return nil
+	case *map[string]any:
+		for key := range conf.data[""] {
+			if _, exists := conf.data[key]; exists {
+				return errors.New(at("decode", conf.filename) + " global key \"" + key + "\" has the name of a section")
+			}
+		}
+		if *m == nil {
+			*m = make(map[string]any)
+		}
+		for section, keys := range conf.data {
+			values, err := conf.decodeAny(section, keys)
+			if err != nil {
+				return err
+			}
+			if section != "" {
+				(*m)[section] = values
+				continue
+			}
+			for key, value := range values {
+				(*m)[key] = value
+			}
+		}
+		return nil
+	}
+	rv, err := structPointer("decode", v)
+	if err != nil {
+		return err
+	}
+	return conf.unmarshal(rv, "", true)
+}
+
+// decodeAny returns the values of the keys of section, list keys as []string.
+func (conf *Conf) decodeAny(section string, keys map[string]string) (map[string]any, error) {
+	values := make(map[string]any, len(keys))
+	for key := range keys {
+		if _, isList := conf.lists[section][key]; isList {
+			list, err := conf.ReadSlice(section, key)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = list
+			continue
+		}
+		value, err := conf.Read(section, key)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
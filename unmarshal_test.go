@@ -0,0 +1,319 @@
+package conf
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeGlobalKeyCollision(t *testing.T) {
+	conf, err := ParseString("a=1\n[a]\nk=v\n", AllowGlobalKeys())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := conf.Decode(&m); err == nil || !strings.Contains(err.Error(), "has the name of a section") {
+		t.Errorf("Decode error = %v, want a collision error", err)
+	}
+}
+
+func TestUnmarshalTextUnmarshalerSlice(t *testing.T) {
+	var v struct {
+		IP  net.IP `conf:"s.ip"`
+		Def net.IP `conf:"s.def,default=10.0.0.2"`
+	}
+	conf, err := ParseString("[s]\nip=10.0.0.1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Unmarshal(&v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.IP.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Errorf("IP = %v, want 10.0.0.1", v.IP)
+	}
+	if !v.Def.Equal(net.IPv4(10, 0, 0, 2)) {
+		t.Errorf("Def = %v, want 10.0.0.2", v.Def)
+	}
+}
+
+type pipeList []string
+
+func TestUnmarshalDecodeHookSlice(t *testing.T) {
+	hook := DecodeHook(func(value string) (pipeList, error) {
+		return strings.Split(value, "|"), nil
+	})
+	conf, err := ParseString("[s]\nx=a|b,c\n", hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v struct {
+		X pipeList `conf:"s.x"`
+		Y pipeList `conf:"s.y,default=d|e"`
+	}
+	if err := conf.Unmarshal(&v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (pipeList{"a", "b,c"}); !reflect.DeepEqual(v.X, want) {
+		t.Errorf("X = %q, want %q", v.X, want)
+	}
+	if want := (pipeList{"d", "e"}); !reflect.DeepEqual(v.Y, want) {
+		t.Errorf("Y = %q, want %q", v.Y, want)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	type config struct {
+		Debug    bool          `conf:"debug"`
+		Host     string        `conf:"server.host"`
+		Port     int           `conf:"server.port"`
+		Small    uint8         `conf:"server.small"`
+		Ratio    float64       `conf:"server.ratio"`
+		Timeout  time.Duration `conf:"server.timeout"`
+		Started  time.Time     `conf:"server.started"`
+		Tags     []string      `conf:"server.tags"`
+		Ports    []int         `conf:"server.ports"`
+		Name     *string       `conf:"server.name"`
+		Kept     string        `conf:"server.missing"`
+		Skipped  string        `conf:"-"`
+		Untagged string
+		hidden   string
+	}
+	conf := parseTest(t, "debug=yes\nuntagged=u\nskipped=s\nhidden=h\n[server]\nhost=h\nport=8080\nsmall=7\nratio=0.5\ntimeout=2s\nstarted=2024-05-01T10:00:00Z\ntags=a, b\nports[]=1\nports[]=2\nname=n\n", AllowGlobalKeys())
+	v := config{Kept: "kept"}
+	if err := conf.Unmarshal(&v); err != nil {
+		t.Fatal(err)
+	}
+	name := "n"
+	want := config{
+		Debug: true, Host: "h", Port: 8080, Small: 7, Ratio: 0.5, Timeout: 2 * time.Second,
+		Started: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Tags: []string{"a", "b"}, Ports: []int{1, 2},
+		Name: &name, Kept: "kept", Untagged: "u",
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("Unmarshal = %+v, want %+v", v, want)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		v     any
+	}{
+		{"invalid int", "[s]\nk=x\n", &struct {
+			K int `conf:"s.k"`
+		}{}},
+		{"overflow", "[s]\nk=300\n", &struct {
+			K uint8 `conf:"s.k"`
+		}{}},
+		{"invalid list element", "[s]\nk=1,x\n", &struct {
+			K []int `conf:"s.k"`
+		}{}},
+		{"unsupported type", "[s]\nk=1\n", &struct {
+			K complex64 `conf:"s.k"`
+		}{}},
+		{"no pointer", "", struct{}{}},
+		{"nil pointer", "", (*struct{})(nil)},
+		{"no struct", "", new(int)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := parseTest(t, tt.input).Unmarshal(tt.v); err == nil {
+				t.Error("Unmarshal returned no error")
+			}
+		})
+	}
+}
+
+func TestUnmarshalSection(t *testing.T) {
+	var v struct {
+		Port int    `conf:"port"`
+		Host string `conf:"host"`
+	}
+	conf := parseTest(t, "[server]\nport=8080\nhost=h\n[other]\nport=1\n")
+	if err := conf.UnmarshalSection("server", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Port != 8080 || v.Host != "h" {
+		t.Errorf("UnmarshalSection = %+v", v)
+	}
+	if err := conf.UnmarshalSection("missing", &v); errors.Is(err, ErrSectionNotFound) {
+		t.Errorf("UnmarshalSection(missing) error = %v, want fields left unchanged", err)
+	}
+}
+
+func TestUnmarshalNested(t *testing.T) {
+	type tls struct {
+		Cert string `conf:"cert"`
+	}
+	type server struct {
+		Port int `conf:"port"`
+		TLS  tls
+	}
+	var v struct {
+		Server  server
+		DB      *struct{ DSN string }
+		Missing *struct{ X string }
+	}
+	conf := parseTest(t, "[server]\nport=1\n[server.tls]\ncert=c\n[db]\ndsn=d\n")
+	if err := conf.Unmarshal(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Server.Port != 1 || v.Server.TLS.Cert != "c" {
+		t.Errorf("Server = %+v", v.Server)
+	}
+	if v.DB == nil || v.DB.DSN != "d" {
+		t.Errorf("DB = %+v, want the keys of [db]", v.DB)
+	}
+	if v.Missing != nil {
+		t.Errorf("Missing = %+v, want nil without its section", v.Missing)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	conf := parseTest(t, "g=1\n[s]\nk=v\nlist[]=a\nlist[]=b\n", AllowGlobalKeys())
+
+	sections := map[string]map[string]string{"old": {"x": "y"}}
+	if err := conf.Decode(&sections); err != nil {
+		t.Fatal(err)
+	}
+	wantSections := map[string]map[string]string{"old": {"x": "y"}, "": {"g": "1"}, "s": {"k": "v", "list": "b"}}
+	if !reflect.DeepEqual(sections, wantSections) {
+		t.Errorf("Decode(map[string]map[string]string) = %v, want %v", sections, wantSections)
+	}
+
+	var anys map[string]any
+	if err := conf.Decode(&anys); err != nil {
+		t.Fatal(err)
+	}
+	wantAnys := map[string]any{"g": "1", "s": map[string]any{"k": "v", "list": []string{"a", "b"}}}
+	if !reflect.DeepEqual(anys, wantAnys) {
+		t.Errorf("Decode(map[string]any) = %v, want %v", anys, wantAnys)
+	}
+
+	var v struct {
+		K string `conf:"s.k"`
+	}
+	if err := conf.Decode(&v); err != nil || v.K != "v" {
+		t.Errorf("Decode(struct) = %+v, %v", v, err)
+	}
+	if err := conf.Decode(new(int)); err == nil {
+		t.Error("Decode(*int) returned no error")
+	}
+}
+
+func TestUnmarshalDefaults(t *testing.T) {
+	var v struct {
+		Port    int           `conf:"s.port,default=8080"`
+		Set     int           `conf:"s.set,default=1"`
+		Text    string        `conf:"s.text,default=a, b"`
+		List    []string      `conf:"s.list,default=a, b"`
+		Timeout time.Duration `conf:"s.timeout,default=5s"`
+	}
+	if err := parseTest(t, "[s]\nset=2\n").Unmarshal(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Port != 8080 || v.Set != 2 || v.Text != "a, b" || !reflect.DeepEqual(v.List, []string{"a", "b"}) || v.Timeout != 5*time.Second {
+		t.Errorf("Unmarshal = %+v", v)
+	}
+
+	var invalid struct {
+		Port int `conf:"s.port,default=http"`
+	}
+	if err := parseTest(t, "[s]\n").Unmarshal(&invalid); err == nil || !strings.Contains(err.Error(), "default") {
+		t.Errorf("Unmarshal of an invalid default error = %v", err)
+	}
+}
+
+func TestUnmarshalRequired(t *testing.T) {
+	var v struct {
+		DSN  string `conf:"db.dsn,required"`
+		User string `conf:"db.user,required"`
+		Host string `conf:"server.host,required"`
+		Port int    `conf:"server.port,required,default=80"`
+	}
+	err := parseTest(t, "[db]\ndsn=d\n").Unmarshal(&v)
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Unmarshal error = %v, want ErrKeyNotFound", err)
+	}
+	for _, key := range []string{"user", "host", "port"} {
+		if !strings.Contains(err.Error(), "\""+key+"\"") {
+			t.Errorf("Unmarshal error %q does not name key %q", err, key)
+		}
+	}
+	if v.DSN != "d" || v.Port != 80 {
+		t.Errorf("Unmarshal = %+v, want existing keys and defaults stored", v)
+	}
+	if err := parseTest(t, "[db]\ndsn=d\nuser=u\n[server]\nhost=h\nport=1\n").Unmarshal(&v); err != nil {
+		t.Errorf("Unmarshal with all required keys error = %v", err)
+	}
+}
+
+func TestUnmarshalDecodeHook(t *testing.T) {
+	seconds := DecodeHook(func(value string) (time.Duration, error) {
+		n, err := strconv.Atoi(value)
+		return time.Duration(n) * time.Second, err
+	})
+	var v struct {
+		Timeout time.Duration `conf:"s.timeout"`
+	}
+	if err := parseTest(t, "[s]\ntimeout=5\n", seconds).Unmarshal(&v); err != nil || v.Timeout != 5*time.Second {
+		t.Errorf("Unmarshal = %+v, %v, want 5s from the hook", v, err)
+	}
+	if err := parseTest(t, "[s]\ntimeout=5s\n", seconds).Unmarshal(&v); err == nil {
+		t.Error("Unmarshal of a value rejected by the hook returned no error")
+	}
+}
+
+type embeddedBase struct {
+	Name string `conf:"s.name"`
+}
+
+type EmbeddedPointer struct {
+	Level int `conf:"s.level"`
+}
+
+func TestUnmarshalEmbedded(t *testing.T) {
+	var v struct {
+		embeddedBase
+		*EmbeddedPointer
+		Port int `conf:"s.port"`
+	}
+	if err := parseTest(t, "[s]\nname=n\nlevel=3\nport=1\n").Unmarshal(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "n" || v.EmbeddedPointer == nil || v.Level != 3 || v.Port != 1 {
+		t.Errorf("Unmarshal = %+v", v)
+	}
+}
+
+func TestUnmarshalRepeatedSections(t *testing.T) {
+	type upstream struct {
+		Name string `conf:",name"`
+		URL  string `conf:"url"`
+	}
+	var v struct {
+		Upstreams []upstream  `conf:"upstream"`
+		Pointers  []*upstream `conf:"upstream"`
+		None      []upstream  `conf:"none"`
+	}
+	conf := parseTest(t, "[upstream \"b\"]\nurl=B\n[other]\n[upstream \"a \\\"q\\\"\"]\nurl=A\n[upstream]\nurl=plain\n")
+	if err := conf.Unmarshal(&v); err != nil {
+		t.Fatal(err)
+	}
+	want := []upstream{{"b", "B"}, {"a \"q\"", "A"}}
+	if !reflect.DeepEqual(v.Upstreams, want) {
+		t.Errorf("Upstreams = %+v, want %+v", v.Upstreams, want)
+	}
+	if len(v.Pointers) != 2 || *v.Pointers[1] != want[1] {
+		t.Errorf("Pointers = %+v, want %+v", v.Pointers, want)
+	}
+	if v.None != nil {
+		t.Errorf("None = %+v, want nil without sections", v.None)
+	}
+}
@@ -0,0 +1,47 @@
+package conf
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// warn records a warning at the current position, it is reported at the end of the line.
+func (lex *lexer) warn(msg string) {
+	if lex.opts.warn == nil {
+		return
+	}
+	lex.warnings = append(lex.warnings, &ParseError{File: lex.file, Line: lex.line, Col: lex.col, Msg: msg})
+}
+
+// check warns about a character about to be read if it is likely a mistake.
+func (lex *lexer) check(chr string) {
+	if lex.opts.warn == nil {
+		return
+	}
+	r, size := utf8.DecodeRuneInString(chr)
+	switch {
+	case r == utf8.RuneError && size == 1:
+		lex.warn("invalid UTF-8 byte " + strconv.Quote(chr))
+	case r != '\t' && unicode.IsControl(r):
+		lex.warn("control character " + strconv.QuoteRune(r))
+	case r == '\u00a0' || r == '\u200b' || r == '\ufeff':
+		lex.warn("invisible character " + strconv.QuoteRuneToASCII(r))
+	}
+}
+
+// endLine reports the warnings of the current line.
+func (lex *lexer) endLine() {
+	if lex.opts.warn == nil {
+		return
+	}
+	if text := strings.TrimRight(lex.text, " 	"); text != lex.text {
+		lex.warnings = append(lex.warnings, &ParseError{File: lex.file, Line: lex.line, Col: utf8.RuneCountInString(text) + 1, Msg: "trailing whitespace"})
+	}
+	for _, warning := range lex.warnings {
+		warning.Snippet = lex.text
+		lex.opts.warn(warning)
+	}
+	lex.warnings = nil
+}
@@ -0,0 +1,441 @@
+package conf
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Save writes the sections and keys back to the file the conf was opened from.
+// Comments and layout of the original file are preserved, see WriteTo.
+// Only confs from Open, or a snapshot of one, have a filename; an error will be returned for other confs.
+func (conf *Conf) Save() error {
+	if conf.filename == "" {
+		return errors.New("save: conf has no filename")
+	}
+	file, err := os.Create(conf.filename)
+	if err != nil {
+		return err
+	}
+	if _, err := conf.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// SaveAtomic is like Save but writes to a temporary file in the same directory first,
+// syncs it to disk and renames it over the original file.
+// Readers of the file never observe a partially written conf.
+func (conf *Conf) SaveAtomic() error {
+	if conf.filename == "" {
+		return errors.New("saveatomic: conf has no filename")
+	}
+	file, err := os.CreateTemp(filepath.Dir(conf.filename), "."+filepath.Base(conf.filename)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if info, err := os.Stat(conf.filename); err == nil {
+		if err := file.Chmod(info.Mode().Perm()); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if _, err := conf.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), conf.filename)
+}
+
+// WriteTo writes the sections and keys in conf file format to w.
+// If the conf was parsed from a file, its comments, blank lines and ordering are reproduced
+// and only edited keys and sections differ. Keys and sections added later are written in sorted order.
+// An error will be returned if a section or key name set later would be read back as something else,
+// e.g. a key containing the delimiter, or if a changed value cannot be written in the dialect of Systemd
+// or WindowsINI, e.g. a value with a line break. It implements io.WriterTo.
+func (conf *Conf) WriteTo(w io.Writer) (int64, error) {
+	if err := conf.representable(); err != nil {
+		return 0, err
+	}
+	n, err := io.WriteString(w, conf.format())
+	return int64(n), err
+}
+
+// representable returns an error if a name or changed value cannot be written in the dialect of the conf and read back.
+// Names of the parsed file are valid, values of the default format can always be written in quotes.
+func (conf *Conf) representable() error {
+	parsed := make(map[[2]string][]item)
+	headers := make(map[string]bool)
+	for _, it := range conf.layout {
+		if it.kind == itemKey && !it.shadowed {
+			parsed[[2]string{it.section, it.key}] = append(parsed[[2]string{it.section, it.key}], it)
+		} else if it.kind == itemSection {
+			headers[it.section] = true
+		}
+	}
+	for _, section := range sortedKeys(conf.data) {
+		if msg := conf.invalidSection(section); msg != "" && !headers[section] {
+			return errors.New(at("writeto", conf.filename) + " section name \"" + section + "\" " + msg)
+		}
+		for _, key := range sortedKeys(conf.data[section]) {
+			if msg := conf.invalidKey(key); msg != "" && len(parsed[[2]string{section, key}]) == 0 {
+				return errors.New(at("writeto", conf.filename) + " key name \"" + key + "\" in section \"" + section + "\" " + msg)
+			}
+			if !conf.opts.systemd && !conf.opts.windows {
+				continue
+			}
+			if items := parsed[[2]string{section, key}]; len(items) > 0 && conf.unchanged(section, key, items) || len(items) == 0 && conf.fromInclude(section, key) {
+				continue
+			}
+			values, isList := conf.lists[section][key]
+			if !isList {
+				values = []string{conf.data[section][key]}
+			}
+			for _, value := range values {
+				if msg := conf.unrepresentable(value, isList); msg != "" {
+					return errors.New(at("writeto", conf.filename) + " value of key \"" + key + "\" in section \"" + section + "\" " + msg)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// invalidSection describes why a section name cannot be written as a header, it is empty if it can.
+func (conf *Conf) invalidSection(section string) string {
+	switch {
+	case strings.ContainsAny(section, "\n\r"):
+		return "has a line break"
+	case section != strings.Trim(section, " \t"):
+		return "has leading or trailing whitespace"
+	case strings.ContainsAny(section, "[]"):
+		return "contains a bracket"
+	case conf.opts.inheritance && strings.Contains(section, ":"):
+		return "contains the colon of a parent section"
+	}
+	return ""
+}
+
+// invalidKey describes why a key name cannot be written as a key line, it is empty if it can.
+func (conf *Conf) invalidKey(key string) string {
+	switch {
+	case key == "":
+		return "is empty"
+	case strings.ContainsAny(key, "\n\r"):
+		return "has a line break"
+	case key != strings.Trim(key, " \t"):
+		return "has leading or trailing whitespace"
+	case strings.ContainsAny(key, conf.opts.delimiters):
+		return "contains a delimiter"
+	case strings.HasPrefix(key, "[") || strings.HasSuffix(key, "[]"):
+		return "contains a bracket"
+	}
+	for _, prefix := range conf.opts.comments {
+		if prefix != "" && strings.HasPrefix(key, prefix) {
+			return "starts with a comment prefix"
+		}
+	}
+	return ""
+}
+
+// unrepresentable describes why a value cannot be written in the dialect of the conf, it is empty if it can.
+func (conf *Conf) unrepresentable(value string, isList bool) string {
+	switch {
+	case strings.ContainsAny(value, "\n\r"):
+		return "has a line break"
+	case !conf.opts.systemd:
+	case value != strings.Trim(value, " \t"):
+		return "has leading or trailing whitespace"
+	case strings.HasSuffix(value, "\\"):
+		return "ends with a backslash that continues the line"
+	case value == "" && isList:
+		return "is an empty list element that resets the key"
+	}
+	return ""
+}
+
+// String returns the sections and keys in canonical conf file format:
+// sections and keys in sorted order without the comments and layout of the original file.
+// It implements fmt.Stringer.
+func (conf *Conf) String() string {
+	return (&Conf{data: conf.data, lists: conf.lists, parents: conf.parents, opts: conf.opts}).format()
+}
+
+// format renders the data in conf file format.
+// The layout of a parsed file including comments is reproduced, changed keys are rewritten in place,
+// new keys are added after the last key of their section and new sections are appended.
+func (conf *Conf) format() string {
+	var b strings.Builder
+	parsed := make(map[string]map[string][]item)
+	last := make(map[string]int)
+	for i, it := range conf.layout {
+		if it.kind == itemText || it.shadowed {
+			continue
+		}
+		if parsed[it.section] == nil {
+			parsed[it.section] = make(map[string][]item)
+		}
+		if it.kind == itemKey {
+			parsed[it.section][it.key] = append(parsed[it.section][it.key], it)
+		}
+		last[it.section] = i
+	}
+
+	// Global keys not in the layout are written first, they cannot follow a section.
+	if _, exists := parsed[""]; !exists {
+		for _, key := range conf.ownKeys("") {
+			b.WriteString(conf.formatKeys("", key, nil))
+		}
+	}
+
+	rewritten := make(map[[2]string]bool)
+	for i := 0; i < len(conf.layout); i++ {
+		it := conf.layout[i]
+		keys, exists := conf.data[it.section]
+		if !exists {
+			if it.kind == itemText && it.section == "" {
+				b.WriteString(it.text)
+			}
+			continue
+		}
+		switch it.kind {
+		case itemText, itemSection:
+			b.WriteString(it.text)
+		case itemKey:
+			if _, exists := keys[it.key]; !exists {
+				break
+			}
+			if it.shadowed {
+				b.WriteString(it.text)
+				break
+			}
+			if conf.unchanged(it.section, it.key, parsed[it.section][it.key]) {
+				b.WriteString(it.text)
+				break
+			}
+			// A changed key is rewritten completely at its first line.
+			if !rewritten[[2]string{it.section, it.key}] {
+				rewritten[[2]string{it.section, it.key}] = true
+				lines := conf.formatKeys(it.section, it.key, parsed[it.section][it.key])
+				if !strings.HasSuffix(it.text, "\n") {
+					lines = strings.TrimSuffix(lines, conf.newline())
+				}
+				b.WriteString(lines)
+			}
+		}
+		if end, ok := last[it.section]; !ok || end != i {
+			continue
+		}
+		var added []string
+		for _, key := range conf.ownKeys(it.section) {
+			if len(parsed[it.section][key]) == 0 {
+				added = append(added, key)
+			}
+		}
+		if len(added) == 0 {
+			continue
+		}
+		// New keys start on the line following the last item of the section.
+		rest := ""
+		if !strings.HasSuffix(b.String(), "\n") {
+			if i+1 < len(conf.layout) && conf.layout[i+1].kind == itemText {
+				// The text following the last item, e.g. an inline comment, stays on its line.
+				line, after, found := strings.Cut(conf.layout[i+1].text, "\n")
+				if b.WriteString(line); found {
+					b.WriteString("\n")
+				} else {
+					b.WriteString(conf.newline())
+				}
+				rest = after
+				i++
+			} else {
+				b.WriteString(conf.newline())
+			}
+		}
+		for _, key := range added {
+			b.WriteString(conf.formatKeys(it.section, key, nil))
+		}
+		b.WriteString(rest)
+	}
+
+	for _, section := range sortedKeys(conf.data) {
+		keys := conf.ownKeys(section)
+		if _, exists := parsed[section]; exists || section == "" {
+			continue
+		}
+		if _, included := conf.includedSection(section); included && len(keys) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			if !strings.HasSuffix(b.String(), "\n") {
+				b.WriteString(conf.newline())
+			}
+			b.WriteString(conf.newline())
+		}
+		b.WriteString(conf.header(section) + conf.newline())
+		for _, key := range keys {
+			b.WriteString(conf.formatKeys(section, key, nil))
+		}
+	}
+	if conf.opts.windows {
+		return strings.ReplaceAll(strings.ReplaceAll(b.String(), "\r\n", "\n"), "\n", "\r\n")
+	}
+	return b.String()
+}
+
+// newline returns the line ending of new and rewritten lines, \r\n for WindowsINI or if the parsed file used it.
+func (conf *Conf) newline() string {
+	if conf.opts.windows {
+		return "\r\n"
+	}
+	for _, it := range conf.layout {
+		if i := strings.IndexByte(it.text, '\n'); i > 0 && it.text[i-1] == '\r' {
+			return "\r\n"
+		} else if i >= 0 {
+			return "\n"
+		}
+	}
+	return "\n"
+}
+
+// ownKeys returns the sorted keys of section without the keys that still have the values of an included file,
+// which are not written back to the including file.
+func (conf *Conf) ownKeys(section string) []string {
+	var keys []string
+	for _, key := range sortedKeys(conf.data[section]) {
+		if !conf.fromInclude(section, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// includedSection returns the name of section in the included files and whether it exists there.
+func (conf *Conf) includedSection(section string) (string, bool) {
+	if conf.included == nil {
+		return "", false
+	}
+	return findSection(conf.included.data, section, conf.opts.caseInsensitive)
+}
+
+// fromInclude reports whether a key was read from an included file and still has the same values.
+func (conf *Conf) fromInclude(section, key string) bool {
+	name, exists := conf.includedSection(section)
+	if !exists {
+		return false
+	}
+	k, exists := find(conf.included.data[name], key, conf.opts.caseInsensitive)
+	if !exists || conf.included.data[name][k] != conf.data[section][key] {
+		return false
+	}
+	list, isList := conf.included.lists[name][k]
+	own, ownList := conf.lists[section][key]
+	return isList == ownList && strings.Join(list, "\n") == strings.Join(own, "\n")
+}
+
+// unchanged reports whether a key still has the values of its parsed items.
+func (conf *Conf) unchanged(section, key string, items []item) bool {
+	list, isList := conf.lists[section][key]
+	if !isList {
+		list = []string{conf.data[section][key]}
+	}
+	if len(items) != len(list) {
+		return false
+	}
+	for i, it := range items {
+		if it.value != list[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatKeys renders all lines of a key, one line per element for list keys.
+// List elements are written as key[]=value unless the key was parsed as repeated key=value lines
+// or the Systemd dialect is used.
+func (conf *Conf) formatKeys(section, key string, items []item) string {
+	list, isList := conf.lists[section][key]
+	if !isList {
+		return conf.formatKey(key, conf.data[section][key])
+	}
+	if !conf.opts.systemd && (len(items) == 0 || items[0].list) {
+		key += "[]"
+	}
+	lines := ""
+	for _, value := range list {
+		lines += conf.formatKey(key, value)
+	}
+	return lines
+}
+
+// formatKey renders a single key line, quoting the value if necessary.
+func (conf *Conf) formatKey(key, value string) string {
+	if conf.needsQuotes(value) && conf.opts.windows {
+		value = "\"" + value + "\""
+	} else if conf.needsQuotes(value) {
+		value = "\"" + quoteEscaper.Replace(value) + "\""
+	}
+	return key + "=" + value + conf.newline()
+}
+
+// needsQuotes reports whether value would be read differently if written without quotes.
+func (conf *Conf) needsQuotes(value string) bool {
+	if conf.opts.systemd { //values that would need quotes are rejected by representable
+		return false
+	}
+	if conf.opts.windows {
+		return value != strings.Trim(value, " \t") || len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"")
+	}
+	if value != strings.Trim(value, " \t") || strings.HasPrefix(value, "\"") || strings.HasSuffix(value, "\\") {
+		return true
+	}
+	comments := conf.opts.comments
+	if comments == nil {
+		comments = defaultComments
+	}
+	for _, comment := range comments {
+		if comment != "" && (strings.Contains(value, " "+comment) || strings.Contains(value, "\t"+comment)) {
+			return true
+		}
+	}
+	return strings.ContainsAny(value, "\n\r")
+}
+
+// rewrite renders a key item with a new key or value.
+// The line ending is only kept if the original text had one, an inline comment may follow otherwise.
+func (conf *Conf) rewrite(it item, key, value string) string {
+	if it.list {
+		key += "[]"
+	}
+	line := conf.formatKey(key, value)
+	if !strings.HasSuffix(it.text, "\n") {
+		line = strings.TrimSuffix(line, conf.newline())
+	}
+	return line
+}
+
+// quoteEscaper escapes values written in quotes, see escapes.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n", "\t", "\\t")
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package conf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"sections", "[a]\nk=v\n\n[b]\nx = 1\n"},
+		{"comments", "; header\n[a]\n# note\nk=v ; inline\n"},
+		{"empty value comment", "[a]\nk= ; comment\n"},
+		{"crlf", "[a]\r\nk=v\r\n\r\n[b]\r\nx=1\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := ParseString(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if _, err := conf.WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.input {
+				t.Errorf("WriteTo = %q, want %q", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestWriteKeepsLineEnding(t *testing.T) {
+	conf, err := ParseString("[a]\r\nk=v\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Set("a", "k", "w")
+	conf.Set("a", "j", "x")
+	var buf bytes.Buffer
+	if _, err := conf.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); bytes.Count(buf.Bytes(), []byte("\n")) != bytes.Count(buf.Bytes(), []byte("\r\n")) {
+		t.Errorf("WriteTo = %q, want CRLF line endings only", got)
+	}
+	reparsed, err := ParseString(buf.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reparsed.ReadDefault("a", "k", ""); got != "w" {
+		t.Errorf("a.k = %q, want %q", got, "w")
+	}
+	if got := reparsed.ReadDefault("a", "j", ""); got != "x" {
+		t.Errorf("a.j = %q, want %q", got, "x")
+	}
+}
+
+func TestWriteAddedKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"trailing newline", "[s]\nk=v ; c\n", "[s]\nk=w ; c\nj=1\n"},
+		{"no trailing newline", "[s]\nk=v", "[s]\nk=w\nj=1\n"},
+		{"inline comment without trailing newline", "[s]\nk=v ; c", "[s]\nk=w ; c\nj=1\n"},
+		{"comment after section", "[s]\nk=v\n; c\n\n[t]\n", "[s]\nk=w\nj=1\n; c\n\n[t]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := ParseString(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf.Set("s", "k", "w")
+			conf.Set("s", "j", "1")
+			if got := conf.format(); got != tt.want {
+				t.Errorf("format = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteUnrepresentable(t *testing.T) {
+	tests := []struct {
+		name  string
+		opt   Option
+		value string
+	}{
+		{"systemd line break", Systemd(), "a\nb"},
+		{"systemd trailing backslash", Systemd(), `a\`},
+		{"systemd leading space", Systemd(), " a"},
+		{"windows line break", WindowsINI(), "a\nb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := ParseString("[a]\nk=v\n", tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf.Set("a", "k", tt.value)
+			var buf bytes.Buffer
+			if _, err := conf.WriteTo(&buf); err == nil {
+				t.Errorf("WriteTo of %q returned no error", tt.value)
+			}
+		})
+	}
+}
+
+func TestWriteInvalidNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		key     string
+	}{
+		{"key delimiter", "s", "a=b"},
+		{"key section header", "s", "[k]"},
+		{"key list suffix", "s", "k[]"},
+		{"key comment", "s", "#k"},
+		{"key line break", "s", "a\nb"},
+		{"key whitespace", "s", "k "},
+		{"section bracket", "a]b", "k"},
+		{"section line break", "a\nb", "k"},
+		{"section whitespace", " a", "k"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := ParseString("[s]\nk=v\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			conf.Set(tt.section, tt.key, "v")
+			var buf bytes.Buffer
+			if _, err := conf.WriteTo(&buf); err == nil {
+				t.Errorf("WriteTo returned no error and wrote %q", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package conf
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// OpenDefault opens the conf files of an application in the standard locations and merges them like OpenAll.
+// Files are read in order of increasing priority from /etc/app.conf, app/app.conf in the directories of
+// $XDG_CONFIG_DIRS (default /etc/xdg) and $XDG_CONFIG_HOME/app/app.conf (default ~/.config).
+// Missing files are skipped, the names of the files found are returned in the order they were merged.
+func OpenDefault(appName string, opts ...Option) (*Conf, []string, error) {
+	conf := &Conf{data: make(map[string]map[string]string), lists: make(map[string]map[string][]string), opts: options{delimiters: "=", comments: defaultComments}}
+	for _, opt := range opts {
+		opt(&conf.opts)
+	}
+	found := []string{}
+	for _, filename := range defaultPaths(appName) {
+		other, err := Open(filename, opts...)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		conf.merge(other)
+		found = append(found, filename)
+	}
+	return conf, found, nil
+}
+
+// defaultPaths returns the conf files of an application in order of increasing priority, see OpenDefault.
+// Relative directories in the XDG variables are ignored as required by the XDG Base Directory Specification.
+func defaultPaths(app string) []string {
+	paths := []string{filepath.Join("/etc", app+".conf")}
+	dirs := filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS"))
+	if len(dirs) == 0 {
+		dirs = []string{"/etc/xdg"}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- { //the first directory is the most important
+		if filepath.IsAbs(dirs[i]) {
+			paths = append(paths, filepath.Join(dirs[i], app, app+".conf"))
+		}
+	}
+	home := os.Getenv("XDG_CONFIG_HOME")
+	if home == "" {
+		if dir, err := os.UserHomeDir(); err == nil {
+			home = filepath.Join(dir, ".config")
+		}
+	}
+	if filepath.IsAbs(home) {
+		paths = append(paths, filepath.Join(home, app, app+".conf"))
+	}
+	return paths
+}
@@ -0,0 +1,55 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpenDefault(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return filename
+	}
+	system := write("xdg1/conftestapp/conftestapp.conf", "[s]\na=system1\nb=system1\nc=system1\n")
+	write("xdg2/conftestapp/conftestapp.conf", "[s]\na=system2\n")
+	user := write("home/conftestapp/conftestapp.conf", "[s]\na=user\n")
+	system2 := filepath.Join(dir, "xdg2/conftestapp/conftestapp.conf")
+	t.Setenv("XDG_CONFIG_DIRS", filepath.Join(dir, "xdg1")+string(filepath.ListSeparator)+"relative"+string(filepath.ListSeparator)+filepath.Join(dir, "xdg2"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "home"))
+
+	conf, found, err := OpenDefault("conftestapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{system2, system, user}; !reflect.DeepEqual(found, want) {
+		t.Errorf("found %q, want %q", found, want)
+	}
+	if got := conf.ReadDefault("s", "a", ""); got != "user" {
+		t.Errorf("s.a = %q, want %q", got, "user")
+	}
+	if got := conf.ReadDefault("s", "c", ""); got != "system1" {
+		t.Errorf("s.c = %q, want %q", got, "system1")
+	}
+}
+
+func TestOpenDefaultNoFiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_DIRS", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	conf, found, err := OpenDefault("conftestapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 0 || len(conf.Sections()) != 0 {
+		t.Errorf("OpenDefault without files found %q and sections %q", found, conf.Sections())
+	}
+}
@@ -0,0 +1,398 @@
+// Package yaml converts confs to YAML documents and back.
+// It supports the subset of YAML a conf maps to without depending on a YAML library:
+//
+//	global: key before the first section
+//	list:
+//	  - global list key
+//	section:
+//	  key: value
+//	  list: [first element, second element]
+//
+// Top-level scalars and sequences are global keys, top-level mappings are sections.
+// Anchors, tags, block scalars and nested mappings are not supported.
+package yaml
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/hirsch/conf"
+)
+
+// ToYAML returns the sections and keys of c as a YAML document in file order.
+// Values are written as in the file, list keys as sequences.
+// An error will be returned if a global key has the name of a section.
+func ToYAML(c *conf.Conf) ([]byte, error) {
+	b, err := c.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	var out strings.Builder
+	globals, _ := c.Keys("")
+	for _, key := range globals {
+		writeKey(&out, "", key, m[""][key])
+	}
+	for _, section := range c.Sections() {
+		if _, exists := m[""][section]; exists {
+			return nil, errors.New("toyaml: global key \"" + section + "\" has the name of a section")
+		}
+		if len(m[section]) == 0 {
+			out.WriteString(quote(section) + ": {}\n")
+			continue
+		}
+		out.WriteString(quote(section) + ":\n")
+		keys, _ := c.Keys(section)
+		for _, key := range keys {
+			writeKey(&out, "  ", key, m[section][key])
+		}
+	}
+	return []byte(out.String()), nil
+}
+
+// writeKey writes a key with a string value or a list of values at indent.
+func writeKey(out *strings.Builder, indent, key string, value any) {
+	list, isList := value.([]any)
+	if !isList {
+		out.WriteString(indent + quote(key) + ": " + quote(value.(string)) + "\n")
+		return
+	}
+	out.WriteString(indent + quote(key) + ":\n")
+	for _, elem := range list {
+		out.WriteString(indent + "  - " + quote(elem.(string)) + "\n")
+	}
+}
+
+// quote returns s as a plain scalar if it is read back unchanged as a string, in double quotes otherwise.
+// Scalars that YAML 1.1 or 1.2 resolve to booleans, null or numbers are quoted.
+func quote(s string) string {
+	if s == "" || isReserved(s) || s != strings.TrimSpace(s) ||
+		strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") || strings.HasSuffix(s, ":") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// isReserved reports whether a plain scalar s would be read as a boolean, null or number by YAML 1.1 or 1.2,
+// e.g. yes, on, ~, 08, 1e3, .inf or 2001-12-14. Anything starting with a digit is treated as a number.
+func isReserved(s string) bool {
+	switch strings.ToLower(s) {
+	case "~", "null", "true", "false", "yes", "no", "y", "n", "on", "off", ".inf", "+.inf", "-.inf", ".nan":
+		return true
+	}
+	s = strings.TrimLeft(s, "+-")
+	s = strings.TrimPrefix(s, ".")
+	return s != "" && '0' <= s[0] && s[0] <= '9'
+}
+
+// line is a line of a YAML document without its indentation.
+type line struct {
+	num    int
+	indent int
+	text   string
+}
+
+// FromYAML builds a conf from a YAML document as written by ToYAML.
+// Scalars are read as their text, null as an empty value and sequences become list keys.
+func FromYAML(b []byte) (*conf.Conf, error) {
+	lines, err := split(string(b))
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]map[string]any{"": {}}
+	for i := 0; i < len(lines); {
+		l := lines[i]
+		if l.indent != 0 {
+			return nil, syntaxError(l, "unexpected indentation")
+		}
+		key, rest, err := parseKey(l)
+		if err != nil {
+			return nil, err
+		}
+		i++
+		var block []line
+		for i < len(lines) && (lines[i].indent > 0 || isItem(lines[i].text)) {
+			block = append(block, lines[i])
+			i++
+		}
+
+		var value any
+		switch {
+		case rest == "{}":
+			value = map[string]any{}
+		case rest != "":
+			if value, err = parseValue(l, rest); err == nil && len(block) > 0 {
+				err = syntaxError(block[0], "unexpected indentation")
+			}
+		case len(block) == 0:
+			value = ""
+		case isItem(block[0].text):
+			value, err = parseItems(block)
+		default:
+			value, err = parseSection(block)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if keys, isSection := value.(map[string]any); isSection {
+			if _, exists := m[key]; exists || m[""][key] != nil {
+				return nil, syntaxError(l, "duplicate key \""+key+"\"")
+			}
+			m[key] = keys
+			continue
+		}
+		if _, exists := m[key]; exists || m[""][key] != nil {
+			return nil, syntaxError(l, "duplicate key \""+key+"\"")
+		}
+		m[""][key] = value
+	}
+	if len(m[""]) == 0 {
+		delete(m, "")
+	}
+
+	b, err = json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return conf.FromJSON(b)
+}
+
+// parseSection parses the indented keys of a section.
+func parseSection(block []line) (map[string]any, error) {
+	keys := make(map[string]any)
+	indent := block[0].indent
+	for i := 0; i < len(block); {
+		l := block[i]
+		if l.indent != indent || isItem(l.text) {
+			return nil, syntaxError(l, "unexpected indentation")
+		}
+		key, rest, err := parseKey(l)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := keys[key]; exists {
+			return nil, syntaxError(l, "duplicate key \""+key+"\"")
+		}
+		i++
+		var items []line
+		for i < len(block) && (block[i].indent > indent || block[i].indent == indent && isItem(block[i].text)) {
+			items = append(items, block[i])
+			i++
+		}
+		switch {
+		case rest != "":
+			if keys[key], err = parseValue(l, rest); err == nil && len(items) > 0 {
+				err = syntaxError(items[0], "unexpected indentation")
+			}
+		case len(items) == 0:
+			keys[key] = ""
+		case !isItem(items[0].text):
+			return nil, syntaxError(items[0], "nested mappings are not supported")
+		default:
+			keys[key], err = parseItems(items)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// parseItems parses the elements of a block sequence.
+func parseItems(block []line) ([]string, error) {
+	list := make([]string, 0, len(block))
+	for _, l := range block {
+		if l.indent != block[0].indent || !isItem(l.text) {
+			return nil, syntaxError(l, "unexpected indentation")
+		}
+		rest := strings.TrimLeft(l.text[1:], " ")
+		if strings.HasPrefix(rest, "[") || strings.HasPrefix(rest, "- ") || rest == "-" {
+			return nil, syntaxError(l, "nested sequences are not supported")
+		}
+		value, rest, err := parseScalar(l, rest, "")
+		if err != nil {
+			return nil, err
+		}
+		if err := trailing(l, rest); err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+// parseKey splits a mapping entry into its key and the rest of the line after the colon.
+func parseKey(l line) (string, string, error) {
+	if strings.HasPrefix(l.text, "\"") || strings.HasPrefix(l.text, "'") {
+		key, rest, err := parseScalar(l, l.text, "")
+		if err != nil {
+			return "", "", err
+		}
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", syntaxError(l, "missing colon after key")
+		}
+		return key, valueText(rest[1:]), nil
+	}
+	i := strings.Index(l.text+" ", ": ")
+	if i < 0 {
+		return "", "", syntaxError(l, "missing colon after key")
+	}
+	if strings.HasPrefix(l.text, "- ") || l.text == "-" {
+		return "", "", syntaxError(l, "unexpected sequence")
+	}
+	return strings.TrimSpace(l.text[:i]), valueText(l.text[min(i+1, len(l.text)):]), nil
+}
+
+// valueText returns the text of a value following a key, empty if only a comment follows.
+// Comments after the value are left to the value parsers, quoted values may contain " #".
+func valueText(s string) string {
+	if s = strings.TrimSpace(s); strings.HasPrefix(s, "#") {
+		return ""
+	}
+	return s
+}
+
+// parseValue parses the value following a key, a scalar or a flow sequence.
+func parseValue(l line, s string) (any, error) {
+	switch {
+	case strings.HasPrefix(s, "|"), strings.HasPrefix(s, ">"):
+		return nil, syntaxError(l, "block scalars are not supported")
+	case strings.HasPrefix(s, "{"):
+		return nil, syntaxError(l, "nested mappings are not supported")
+	case strings.HasPrefix(s, "&"), strings.HasPrefix(s, "*"), strings.HasPrefix(s, "!"):
+		return nil, syntaxError(l, "anchors, aliases and tags are not supported")
+	case !strings.HasPrefix(s, "["):
+		value, rest, err := parseScalar(l, s, "")
+		if err != nil {
+			return nil, err
+		}
+		return value, trailing(l, rest)
+	}
+
+	list := []string{}
+	s = strings.TrimLeft(s[1:], " ")
+	if strings.HasPrefix(s, "]") {
+		return list, trailing(l, s[1:])
+	}
+	for {
+		value, rest, err := parseScalar(l, s, ",]")
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+		switch {
+		case strings.HasPrefix(rest, ","):
+			s = strings.TrimLeft(rest[1:], " ")
+		case strings.HasPrefix(rest, "]"):
+			return list, trailing(l, rest[1:])
+		default:
+			return nil, syntaxError(l, "unterminated flow sequence")
+		}
+	}
+}
+
+// parseScalar parses a quoted or plain scalar at the start of s and returns the rest of s.
+// Plain scalars end at a comment or one of the characters in stop.
+func parseScalar(l line, s, stop string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(s, "\""):
+		end := 1
+		for ; end < len(s) && s[end] != '"'; end++ {
+			if s[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(s) {
+			return "", "", syntaxError(l, "unterminated double quoted scalar")
+		}
+		value, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return "", "", syntaxError(l, "invalid escape in double quoted scalar")
+		}
+		return value, strings.TrimLeft(s[end+1:], " "), nil
+	case strings.HasPrefix(s, "'"):
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '\'' {
+				b.WriteByte(s[i])
+			} else if i+1 < len(s) && s[i+1] == '\'' {
+				b.WriteByte('\'')
+				i++
+			} else {
+				return b.String(), strings.TrimLeft(s[i+1:], " "), nil
+			}
+		}
+		return "", "", syntaxError(l, "unterminated single quoted scalar")
+	}
+	end := len(stripComment(s))
+	if i := strings.IndexAny(s[:end], stop); i >= 0 {
+		end = i
+	}
+	value := strings.TrimSpace(s[:end])
+	if value == "~" || value == "null" || value == "Null" || value == "NULL" {
+		value = ""
+	}
+	return value, s[end:], nil
+}
+
+// trailing returns an error if anything but a comment follows a value.
+func trailing(l line, rest string) error {
+	if rest = strings.TrimSpace(rest); rest != "" && !strings.HasPrefix(rest, "#") {
+		return syntaxError(l, "unexpected \""+rest+"\" after value")
+	}
+	return nil
+}
+
+// stripComment removes a comment starting with " #" from a plain scalar.
+func stripComment(s string) string {
+	if strings.HasPrefix(s, "#") {
+		return ""
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// isItem reports whether text is an element of a block sequence.
+func isItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// split returns the lines of a document that are neither blank nor comments.
+func split(doc string) ([]line, error) {
+	var lines []line
+	for i, text := range strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(text, " ")
+		l := line{i + 1, len(text) - len(trimmed), strings.TrimRight(trimmed, " \t")}
+		if l.text == "" || strings.HasPrefix(l.text, "#") || (l.indent == 0 && (l.text == "---" || l.text == "...")) {
+			continue
+		}
+		if strings.HasPrefix(l.text, "\t") {
+			return nil, syntaxError(l, "tabs are not allowed in indentation")
+		}
+		if l.indent == 0 && (strings.HasPrefix(l.text, "%") || strings.HasPrefix(l.text, "--- ")) {
+			return nil, syntaxError(l, "directives are not supported")
+		}
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// syntaxError returns an error for a line of a document.
+func syntaxError(l line, msg string) error {
+	return errors.New("fromyaml: line " + strconv.Itoa(l.num) + ": " + msg)
+}
@@ -0,0 +1,58 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hirsch/conf"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"sections", "[a]\nk=v\n[b]\nx=1\ny=two words\n"},
+		{"global keys", "g=1\n[a]\nk=v\n"},
+		{"empty value", "[a]\nk=\n"},
+		{"special characters", "[a]\nk=\"v #not a comment\"\nq=\"a \\\"quoted\\\" word\"\n"},
+		{"scalar lookalikes", "[a]\nb=true\nn=null\ni=42\nf=-1.5\no=off\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := conf.ParseString(tt.input, conf.AllowGlobalKeys())
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ToYAML(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := FromYAML(b)
+			if err != nil {
+				t.Fatalf("FromYAML(%q): %v", b, err)
+			}
+			if !reflect.DeepEqual(got.Map(), c.Map()) {
+				t.Errorf("round trip of %q = %v, want %v", b, got.Map(), c.Map())
+			}
+		})
+	}
+}
+
+func TestRoundTripList(t *testing.T) {
+	c, err := conf.ParseString("[a]\nl=1\nl=two\n", conf.CollectDuplicates())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ToYAML(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FromYAML(b)
+	if err != nil {
+		t.Fatalf("FromYAML(%q): %v", b, err)
+	}
+	if want := c.ReadAll("a", "l"); !reflect.DeepEqual(got.ReadAll("a", "l"), want) {
+		t.Errorf("round trip of %q = %q, want %q", b, got.ReadAll("a", "l"), want)
+	}
+}